@@ -0,0 +1,117 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/lazyledger/protobuf3-solidity/generator"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+// optionList collects the values of a flag given several times
+type optionList []string
+
+func (o *optionList) String() string {
+	return strings.Join(*o, ",")
+}
+
+func (o *optionList) Set(value string) error {
+	// Parameters are joined with commas, so a value cannot hold one
+	if !strings.Contains(value, "=") || strings.Contains(value, ",") {
+		return fmt.Errorf("%s must be a single key=value", value)
+	}
+	*o = append(*o, value)
+	return nil
+}
+
+// runGenerate generates Solidity files from a FileDescriptorSet, such as the output of `buf build -o`,
+// without protoc: it builds the CodeGeneratorRequest protoc would send and writes the response files to disk.
+func runGenerate(args []string) error {
+	flags := flag.NewFlagSet("generate", flag.ContinueOnError)
+	descriptorSetPath := flags.String("descriptor_set", "", "path of the binary FileDescriptorSet to read")
+	files := flags.String("files", "", "comma-separated .proto files of the descriptor set to generate")
+	outDir := flags.String("out", ".", "directory to write the generated files to")
+	var opts optionList
+	flags.Var(&opts, "opt", "generator parameter as key=value, may be repeated")
+	err := flags.Parse(args)
+	if err == flag.ErrHelp {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(*descriptorSetPath) == 0 {
+		return errors.New("--descriptor_set is required")
+	}
+	if len(*files) == 0 {
+		return errors.New("--files is required")
+	}
+
+	// Read marshaled descriptor set
+	data, err := ioutil.ReadFile(*descriptorSetPath)
+	if err != nil {
+		return err
+	}
+	descriptorSet := &descriptorpb.FileDescriptorSet{}
+	err = proto.Unmarshal(data, descriptorSet)
+	if err != nil {
+		return fmt.Errorf("invalid descriptor set %s: %v", *descriptorSetPath, err)
+	}
+
+	// Files to generate must be in the set, which also holds their imports
+	fileNames := make(map[string]bool)
+	for _, protoFile := range descriptorSet.GetFile() {
+		fileNames[protoFile.GetName()] = true
+	}
+	fileToGenerate := strings.Split(*files, ",")
+	for _, name := range fileToGenerate {
+		if !fileNames[name] {
+			return fmt.Errorf("file %s is not in descriptor set %s", name, *descriptorSetPath)
+		}
+	}
+
+	// Initialize request object, as protoc would
+	request := &pluginpb.CodeGeneratorRequest{
+		FileToGenerate: fileToGenerate,
+		ProtoFile:      descriptorSet.GetFile(),
+	}
+	if len(opts) > 0 {
+		request.Parameter = proto.String(opts.String())
+	}
+
+	g := generator.New(request, version)
+	err = g.ParseParameters()
+	if err != nil {
+		return err
+	}
+	response, err := g.Generate()
+	if err != nil {
+		return err
+	}
+	if len(response.GetError()) > 0 {
+		return errors.New(response.GetError())
+	}
+
+	// Write response files under the output directory
+	for _, file := range response.GetFile() {
+		path := filepath.Join(*outDir, filepath.FromSlash(file.GetName()))
+		err = os.MkdirAll(filepath.Dir(path), 0755)
+		if err != nil {
+			return err
+		}
+		err = ioutil.WriteFile(path, []byte(file.GetContent()), 0644)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"fmt"
 	"io/ioutil"
 	"os"
 
@@ -12,6 +13,16 @@ import (
 var version = "v0.3.0"
 
 func main() {
+	// Generate from a descriptor set without protoc
+	if len(os.Args) > 1 && os.Args[1] == "generate" {
+		err := runGenerate(os.Args[2:])
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "protoc-gen-sol:", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
 	// Read marshaled request from stdin
 	data, err := ioutil.ReadAll(os.Stdin)
 	if err != nil {
@@ -0,0 +1,51 @@
+package generator
+
+import (
+	"fmt"
+)
+
+// ABIGenerator handles generation of conversions between protobuf and Solidity ABI encodings of messages
+type ABIGenerator struct {
+	g *Generator
+}
+
+// NewABIGenerator creates a new ABI conversion generator
+func NewABIGenerator(g *Generator) *ABIGenerator {
+	return &ABIGenerator{
+		g: g,
+	}
+}
+
+// GenerateToABI generates to_abi, which decodes a protobuf encoded message and returns its ABI encoding.
+// Buffers that are not exactly one valid message revert with InvalidEncoding.
+func (ag *ABIGenerator) GenerateToABI(structName string, b *WriteableBuffer) {
+	b.P("function to_abi(bytes memory protoBuf) internal pure returns (bytes memory) {")
+	b.Indent()
+	b.P("bool success;")
+	b.P("uint64 pos;")
+	b.P(fmt.Sprintf("%s memory instance;", structName))
+	b.P("(success, pos, instance) = decode(0, protoBuf, uint64(protoBuf.length));")
+	b.P("if (!success || pos != protoBuf.length) {")
+	b.Indent()
+	if ag.g.solidityVersion.supportsCustomErrors() {
+		b.P(fmt.Sprintf("revert %sInvalidEncoding();", libraryPrefix(structName)))
+	} else {
+		b.P(`revert("InvalidEncoding");`)
+	}
+	b.Unindent()
+	b.P("}")
+	b.P("return abi.encode(instance);")
+	b.Unindent()
+	b.P("}")
+	b.P0()
+}
+
+// GenerateFromABI generates from_abi, which decodes an ABI encoded message and returns its protobuf encoding
+func (ag *ABIGenerator) GenerateFromABI(structName string, b *WriteableBuffer) {
+	b.P("function from_abi(bytes memory abiBuf) internal pure returns (bytes memory) {")
+	b.Indent()
+	b.P(fmt.Sprintf("return encode(abi.decode(abiBuf, (%s)));", structName))
+	b.Unindent()
+	b.P("}")
+	b.P0()
+}
@@ -0,0 +1,26 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestABIConversionFollowsGeneratedCodecs(t *testing.T) {
+	tests := []struct {
+		parameter      string
+		toABI, fromABI bool
+	}{
+		{"generate=all", true, true},
+		{"generate=decoder", true, false},
+		{"generate=encoder", false, true},
+	}
+	for _, test := range tests {
+		content := generateWithParameter(t, test.parameter, proto3OptionalFile()).GetFile()[0].GetContent()
+		if got := strings.Contains(content, "function to_abi(bytes memory protoBuf)"); got != test.toABI {
+			t.Errorf("%s: to_abi generated %v, want %v", test.parameter, got, test.toABI)
+		}
+		if got := strings.Contains(content, "function from_abi(bytes memory abiBuf)"); got != test.fromABI {
+			t.Errorf("%s: from_abi generated %v, want %v", test.parameter, got, test.fromABI)
+		}
+	}
+}
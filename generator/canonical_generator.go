@@ -0,0 +1,555 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// CanonicalGenerator handles generation of canonical encoding (ADR-027) validators
+type CanonicalGenerator struct {
+	g *Generator
+}
+
+// NewCanonicalGenerator creates a new canonical encoding validator generator
+func NewCanonicalGenerator(g *Generator) *CanonicalGenerator {
+	return &CanonicalGenerator{
+		g: g,
+	}
+}
+
+// GenerateCanonicalHelpers generates the shared helper functions used by the per-message validators.
+// They are emitted in the main library next to the float/double helpers.
+func (cg *CanonicalGenerator) GenerateCanonicalHelpers(b *WriteableBuffer) {
+	b.P("// Helper functions for canonical encoding validation")
+	b.P0()
+
+	b.P("function is_minimal_varint(uint64 pos, bytes memory buf) internal pure returns (bool) {")
+	b.Indent()
+	b.P("// A varint is minimal when its last byte is not a zero continuation group")
+	b.P("for (uint64 i = 0; i < 10; i++) {")
+	b.Indent()
+	b.P("if (pos + i >= buf.length) {")
+	b.Indent()
+	b.P("return false;")
+	b.Unindent()
+	b.P("}")
+	b.P("uint8 current = uint8(buf[pos + i]);")
+	b.P("if (current & 0x80 == 0) {")
+	b.Indent()
+	b.P("return i == 0 || current != 0;")
+	b.Unindent()
+	b.P("}")
+	b.Unindent()
+	b.P("}")
+	b.P("return false;")
+	b.Unindent()
+	b.P("}")
+	b.P0()
+
+	b.P("function is_bytes_less_than(bytes memory buf, uint64 a_pos, uint64 a_len, uint64 b_pos, uint64 b_len) internal pure returns (bool) {")
+	b.Indent()
+	b.P("uint64 min_len = a_len < b_len ? a_len : b_len;")
+	b.P("for (uint64 i = 0; i < min_len; i++) {")
+	b.Indent()
+	b.P("if (buf[a_pos + i] != buf[b_pos + i]) {")
+	b.Indent()
+	b.P("return uint8(buf[a_pos + i]) < uint8(buf[b_pos + i]);")
+	b.Unindent()
+	b.P("}")
+	b.Unindent()
+	b.P("}")
+	b.P("return a_len < b_len;")
+	b.Unindent()
+	b.P("}")
+	b.P0()
+}
+
+// GenerateCanonicalValidator generates the is_canonical validator of a message codec.
+// The validator walks the encoded bytes without allocating the message struct.
+func (cg *CanonicalGenerator) GenerateCanonicalValidator(structName string, descriptor *descriptorpb.DescriptorProto, fieldNameMap map[int32]string, b *WriteableBuffer) error {
+	fields := descriptor.GetField()
+	libraryName := libraryPrefix(structName)
+
+	// Non-packed repeated fields (other than maps) legitimately repeat their field number
+	var repeatableFieldNumbers []int32
+	for _, field := range fields {
+		if isFieldRepeated(field) && !cg.g.isFieldPacked(field) && !cg.g.isMapField(field, descriptor) {
+			repeatableFieldNumbers = append(repeatableFieldNumbers, field.GetNumber())
+		}
+	}
+
+	b.P("function is_canonical(bytes memory buf) internal pure returns (bool) {")
+	b.Indent()
+	b.P("return check_canonical(0, buf, uint64(buf.length), 0);")
+	b.Unindent()
+	b.P("}")
+	b.P0()
+
+	// Nested messages are checked one level deeper, bounded like decoders by max_depth
+	b.P("function check_canonical(uint64 initial_pos, bytes memory buf, uint64 len, uint64 depth) internal pure returns (bool) {")
+	b.Indent()
+	cg.g.generateDepthCheck(structName, b)
+	b.P0()
+	b.P("uint64 pos = initial_pos;")
+	if cg.g.solidityVersion.supportsUnchecked() {
+		// The overflow check below relies on wrapping arithmetic
+		b.P("uint64 end_pos;")
+		b.P("unchecked {")
+		b.Indent()
+		b.P("end_pos = initial_pos + len;")
+		b.Unindent()
+		b.P("}")
+	} else {
+		b.P("uint64 end_pos = initial_pos + len;")
+	}
+	b.P("uint64 previous_field_number = 0;")
+	b.P("if (end_pos < initial_pos || end_pos > buf.length) {")
+	b.Indent()
+	b.P("return false;")
+	b.Unindent()
+	b.P("}")
+	b.P0()
+
+	b.P("while (pos < end_pos) {")
+	b.Indent()
+	b.P("// Keys must be minimal varints")
+	b.P(fmt.Sprintf("if (!%sis_minimal_varint(pos, buf)) {", libraryName))
+	b.Indent()
+	b.P("return false;")
+	b.Unindent()
+	b.P("}")
+	b.P("bool success;")
+	b.P("uint64 field_number;")
+	b.P("ProtobufLib.WireType wire_type;")
+	b.P("(success, pos, field_number, wire_type) = ProtobufLib.decode_key(pos, buf);")
+	b.P("if (!success) {")
+	b.Indent()
+	b.P("return false;")
+	b.Unindent()
+	b.P("}")
+	b.P0()
+
+	b.P("// Fields must be in ascending order")
+	if len(repeatableFieldNumbers) == 0 {
+		b.P("if (field_number <= previous_field_number) {")
+	} else {
+		var conditions []string
+		for _, fieldNumber := range repeatableFieldNumbers {
+			conditions = append(conditions, fmt.Sprintf("field_number != %d", fieldNumber))
+		}
+		b.P("if (field_number < previous_field_number) {")
+		b.Indent()
+		b.P("return false;")
+		b.Unindent()
+		b.P("}")
+		b.P(fmt.Sprintf("if (field_number == previous_field_number && %s) {", strings.Join(conditions, " && ")))
+	}
+	b.Indent()
+	b.P("return false;")
+	b.Unindent()
+	b.P("}")
+	b.P("if (!check_key(field_number, wire_type)) {")
+	b.Indent()
+	b.P("return false;")
+	b.Unindent()
+	b.P("}")
+	b.P0()
+
+	b.P("(success, pos) = check_canonical_field(pos, buf, end_pos, field_number, depth);")
+	b.P("if (!success) {")
+	b.Indent()
+	b.P("return false;")
+	b.Unindent()
+	b.P("}")
+	b.P("previous_field_number = field_number;")
+	b.Unindent()
+	b.P("}")
+	b.P0()
+
+	b.P("return pos == end_pos;")
+	b.Unindent()
+	b.P("}")
+	b.P0()
+
+	b.P("function check_canonical_field(uint64 pos, bytes memory buf, uint64 end_pos, uint64 field_number, uint64 depth) internal pure returns (bool, uint64) {")
+	b.Indent()
+	b.P("bool success;")
+	b.P("uint64 new_pos;")
+	for _, field := range fields {
+		b.P(fmt.Sprintf("if (field_number == %d) {", field.GetNumber()))
+		b.Indent()
+		var err error
+		switch {
+		case cg.g.isMapField(field, descriptor):
+			err = cg.generateMapFieldCheck(field, descriptor, fieldNameMap[field.GetNumber()], libraryName, b)
+		case isFieldRepeated(field) && cg.g.isFieldPacked(field):
+			cg.generatePackedFieldCheck(field, libraryName, b)
+		default:
+			err = cg.generateValueCheck(field, libraryName, b)
+		}
+		if err != nil {
+			return err
+		}
+		b.Unindent()
+		b.P("}")
+	}
+	b.P("return (false, pos); // Unknown field number")
+	b.Unindent()
+	b.P("}")
+	b.P0()
+
+	return nil
+}
+
+// generateLengthCheck generates the shared prologue for length-delimited values: a minimal
+// length prefix that stays within the enclosing message
+func (cg *CanonicalGenerator) generateLengthCheck(libraryName string, b *WriteableBuffer) {
+	b.P(fmt.Sprintf("if (!%sis_minimal_varint(pos, buf)) {", libraryName))
+	b.Indent()
+	b.P("return (false, pos);")
+	b.Unindent()
+	b.P("}")
+	b.P("uint64 size;")
+	b.P("(success, new_pos, size) = ProtobufLib.decode_length_delimited(pos, buf);")
+	b.P("if (!success || new_pos + size > end_pos) {")
+	b.Indent()
+	b.P("return (false, pos);")
+	b.Unindent()
+	b.P("}")
+}
+
+// generateValueCheck generates the check for a single (possibly repeated) field value
+func (cg *CanonicalGenerator) generateValueCheck(field *descriptorpb.FieldDescriptorProto, libraryName string, b *WriteableBuffer) error {
+	isRepeated := isFieldRepeated(field)
+	// Fields tracking their presence are encoded whenever set, and elements of repeated fields whatever their value,
+	// so their default values are accepted
+	hasPresence := cg.g.hasFieldPresence(field) || isRepeated
+
+	switch field.GetType() {
+	case descriptorpb.FieldDescriptorProto_TYPE_INT32,
+		descriptorpb.FieldDescriptorProto_TYPE_INT64,
+		descriptorpb.FieldDescriptorProto_TYPE_UINT32,
+		descriptorpb.FieldDescriptorProto_TYPE_UINT64,
+		descriptorpb.FieldDescriptorProto_TYPE_SINT32,
+		descriptorpb.FieldDescriptorProto_TYPE_SINT64,
+		descriptorpb.FieldDescriptorProto_TYPE_BOOL,
+		descriptorpb.FieldDescriptorProto_TYPE_ENUM:
+		b.P(fmt.Sprintf("if (!%sis_minimal_varint(pos, buf)) {", libraryName))
+		b.Indent()
+		b.P("return (false, pos);")
+		b.Unindent()
+		b.P("}")
+		b.P("uint64 value;")
+		b.P("(success, new_pos, value) = ProtobufLib.decode_varint(pos, buf);")
+		switch {
+		case field.GetType() == descriptorpb.FieldDescriptorProto_TYPE_BOOL && hasPresence:
+			b.P("// Booleans are encoded as 0 or 1")
+			b.P("if (!success || value > 1) {")
+		case field.GetType() == descriptorpb.FieldDescriptorProto_TYPE_BOOL:
+			b.P("// Default values must be omitted, and true is encoded as 1")
+			b.P("if (!success || value != 1) {")
+		case hasPresence:
+			b.P("if (!success) {")
+		default:
+			b.P("// Default values must be omitted")
+			b.P("if (!success || value == 0) {")
+		}
+		b.Indent()
+		b.P("return (false, pos);")
+		b.Unindent()
+		b.P("}")
+	case descriptorpb.FieldDescriptorProto_TYPE_FIXED32,
+		descriptorpb.FieldDescriptorProto_TYPE_SFIXED32,
+		descriptorpb.FieldDescriptorProto_TYPE_FLOAT:
+		b.P("uint32 value;")
+		b.P("(success, new_pos, value) = ProtobufLib.decode_fixed32(pos, buf);")
+		cg.generateNonDefaultCheck(hasPresence, b)
+	case descriptorpb.FieldDescriptorProto_TYPE_FIXED64,
+		descriptorpb.FieldDescriptorProto_TYPE_SFIXED64,
+		descriptorpb.FieldDescriptorProto_TYPE_DOUBLE:
+		b.P("uint64 value;")
+		b.P("(success, new_pos, value) = ProtobufLib.decode_fixed64(pos, buf);")
+		cg.generateNonDefaultCheck(hasPresence, b)
+	case descriptorpb.FieldDescriptorProto_TYPE_STRING,
+		descriptorpb.FieldDescriptorProto_TYPE_BYTES:
+		cg.generateLengthCheck(libraryName, b)
+		solType, fixedSize, err := fieldTypeOverride(field)
+		if err != nil {
+			return err
+		}
+		if fixedSize > 0 && hasPresence {
+			b.P(fmt.Sprintf("// %s values must be exactly %d bytes", solType, fixedSize))
+			b.P(fmt.Sprintf("if (size != %d) {", fixedSize))
+			b.Indent()
+			b.P("return (false, pos);")
+			b.Unindent()
+			b.P("}")
+		} else if fixedSize > 0 {
+			b.P(fmt.Sprintf("// %s values must be exactly %d bytes, and zero must be omitted", solType, fixedSize))
+			b.P(fmt.Sprintf("if (size != %d) {", fixedSize))
+			b.Indent()
+			b.P("return (false, pos);")
+			b.Unindent()
+			b.P("}")
+			b.P("bytes32 word;")
+			b.P("assembly {")
+			b.Indent()
+			b.P("word := mload(add(add(buf, 32), new_pos))")
+			b.Unindent()
+			b.P("}")
+			b.P(fmt.Sprintf("if (%s == %s(0)) {", fixedValueFromWord(solType, fixedSize, "word"), solType))
+			b.Indent()
+			b.P("return (false, pos);")
+			b.Unindent()
+			b.P("}")
+		} else if isLargeIntegerType(solType) && hasPresence {
+			b.P("// Decimal integers must be valid and without leading zeros")
+			b.P("bool valid;")
+			b.P(fmt.Sprintf("(valid, ) = %sdecode_decimal_%s(buf, new_pos, size);", libraryName, solType))
+			b.P("if (!valid) {")
+			b.Indent()
+			b.P("return (false, pos);")
+			b.Unindent()
+			b.P("}")
+			b.P("if (size > 1) {")
+			b.Indent()
+			NewDecimalGenerator(cg.g).GenerateLeadingZeroCheck(solType, "new_pos", "return (false, pos);", b)
+			b.Unindent()
+			b.P("}")
+		} else if isLargeIntegerType(solType) {
+			b.P("// Decimal integers must be valid and without leading zeros, and zero must be omitted")
+			b.P("bool valid;")
+			b.P(fmt.Sprintf("%s value;", solType))
+			b.P(fmt.Sprintf("(valid, value) = %sdecode_decimal_%s(buf, new_pos, size);", libraryName, solType))
+			b.P("if (!valid || value == 0) {")
+			b.Indent()
+			b.P("return (false, pos);")
+			b.Unindent()
+			b.P("}")
+			NewDecimalGenerator(cg.g).GenerateLeadingZeroCheck(solType, "new_pos", "return (false, pos);", b)
+		} else if !hasPresence {
+			b.P("// Default values must be omitted")
+			b.P("if (size == 0) {")
+			b.Indent()
+			b.P("return (false, pos);")
+			b.Unindent()
+			b.P("}")
+		}
+		b.P("new_pos += size;")
+	case descriptorpb.FieldDescriptorProto_TYPE_MESSAGE:
+		cg.generateLengthCheck(libraryName, b)
+		if isEmbeddedMessageField(field) {
+			typeName, err := cg.g.getSolTypeName(field)
+			if err != nil {
+				return err
+			}
+			b.P("// Embedded messages must be canonical themselves")
+			b.P(fmt.Sprintf("if (!%s.check_canonical(new_pos, buf, size, depth + 1)) {", CodecLibraryName(typeName)))
+			b.Indent()
+			b.P("return (false, pos);")
+			b.Unindent()
+			b.P("}")
+		}
+		b.P("new_pos += size;")
+	default:
+		return fmt.Errorf("unsupported field type %s in canonical validator: %s", field.GetType().String(), field.GetName())
+	}
+	b.P("return (true, new_pos);")
+
+	return nil
+}
+
+// generateNonDefaultCheck generates the check of a decoded fixed size value, which must not be the default unless the field tracks its presence
+func (cg *CanonicalGenerator) generateNonDefaultCheck(hasPresence bool, b *WriteableBuffer) {
+	if hasPresence {
+		b.P("if (!success) {")
+	} else {
+		b.P("// Default values must be omitted")
+		b.P("if (!success || value == 0) {")
+	}
+	b.Indent()
+	b.P("return (false, pos);")
+	b.Unindent()
+	b.P("}")
+}
+
+// generatePackedFieldCheck generates the check for a packed repeated field
+func (cg *CanonicalGenerator) generatePackedFieldCheck(field *descriptorpb.FieldDescriptorProto, libraryName string, b *WriteableBuffer) {
+	cg.generateLengthCheck(libraryName, b)
+	b.P("// Empty packed arrays must be omitted")
+	b.P("if (size == 0) {")
+	b.Indent()
+	b.P("return (false, pos);")
+	b.Unindent()
+	b.P("}")
+	b.P("uint64 packed_end_pos = new_pos + size;")
+
+	switch field.GetType() {
+	case descriptorpb.FieldDescriptorProto_TYPE_FIXED32,
+		descriptorpb.FieldDescriptorProto_TYPE_SFIXED32,
+		descriptorpb.FieldDescriptorProto_TYPE_FLOAT:
+		b.P("if (size % 4 != 0) {")
+		b.Indent()
+		b.P("return (false, pos);")
+		b.Unindent()
+		b.P("}")
+	case descriptorpb.FieldDescriptorProto_TYPE_FIXED64,
+		descriptorpb.FieldDescriptorProto_TYPE_SFIXED64,
+		descriptorpb.FieldDescriptorProto_TYPE_DOUBLE:
+		b.P("if (size % 8 != 0) {")
+		b.Indent()
+		b.P("return (false, pos);")
+		b.Unindent()
+		b.P("}")
+	default:
+		b.P("// Every element must be a minimal varint")
+		b.P("while (new_pos < packed_end_pos) {")
+		b.Indent()
+		b.P(fmt.Sprintf("if (!%sis_minimal_varint(new_pos, buf)) {", libraryName))
+		b.Indent()
+		b.P("return (false, pos);")
+		b.Unindent()
+		b.P("}")
+		b.P("uint64 value;")
+		b.P("(success, new_pos, value) = ProtobufLib.decode_varint(new_pos, buf);")
+		if field.GetType() == descriptorpb.FieldDescriptorProto_TYPE_BOOL {
+			b.P("if (!success || value > 1) {")
+		} else {
+			b.P("if (!success) {")
+		}
+		b.Indent()
+		b.P("return (false, pos);")
+		b.Unindent()
+		b.P("}")
+		b.Unindent()
+		b.P("}")
+		b.P("if (new_pos != packed_end_pos) {")
+		b.Indent()
+		b.P("return (false, pos);")
+		b.Unindent()
+		b.P("}")
+	}
+	b.P("return (true, packed_end_pos);")
+}
+
+// generateMapFieldCheck generates the check for a map field. All entries are consumed at once
+// so that consecutive keys can be compared for strictly ascending order.
+func (cg *CanonicalGenerator) generateMapFieldCheck(field *descriptorpb.FieldDescriptorProto, descriptor *descriptorpb.DescriptorProto, fieldName string, libraryName string, b *WriteableBuffer) error {
+	keyType, _, err := cg.g.getMapKeyValueTypes(field, descriptor)
+	if err != nil {
+		return err
+	}
+	wrapperCodecName := CreateMapEntryWrapperName(fieldName) + "Codec"
+
+	// Key is field 1 of the entry, so its tag is a single byte
+	keyWireType, err := wireTypeNumber(keyType)
+	if err != nil {
+		return err
+	}
+	keyTag := 1<<3 | keyWireType
+
+	isStringKey := keyType == descriptorpb.FieldDescriptorProto_TYPE_STRING
+	if isStringKey {
+		b.P("uint64 previous_key_pos;")
+		b.P("uint64 previous_key_len;")
+	} else {
+		keySolType, err := typeToSol(keyType)
+		if err != nil {
+			return err
+		}
+		b.P(fmt.Sprintf("%s previous_key;", keySolType))
+	}
+	b.P("bool has_previous_key = false;")
+	b.P("while (true) {")
+	b.Indent()
+	cg.generateLengthCheck(libraryName, b)
+	b.P("// Entries must be canonical themselves")
+	b.P(fmt.Sprintf("if (!%s.check_canonical(new_pos, buf, size, depth + 1)) {", wrapperCodecName))
+	b.Indent()
+	b.P("return (false, pos);")
+	b.Unindent()
+	b.P("}")
+	b.P0()
+
+	b.P("// Extract the key, which is absent when it has the default value")
+	b.P(fmt.Sprintf("bool has_key = size > 0 && uint8(buf[new_pos]) == %d;", keyTag))
+	if isStringKey {
+		b.P("uint64 key_pos = new_pos;")
+		b.P("uint64 key_len = 0;")
+		b.P("if (has_key) {")
+		b.Indent()
+		b.P("(success, key_pos, key_len) = ProtobufLib.decode_length_delimited(new_pos + 1, buf);")
+		b.P("if (!success) {")
+		b.Indent()
+		b.P("return (false, pos);")
+		b.Unindent()
+		b.P("}")
+		b.Unindent()
+		b.P("}")
+		b.P(fmt.Sprintf("if (has_previous_key && !%sis_bytes_less_than(buf, previous_key_pos, previous_key_len, key_pos, key_len)) {", libraryName))
+	} else {
+		keySolType, _ := typeToSol(keyType)
+		decodeType, err := typeToDecodeSol(keyType)
+		if err != nil {
+			return err
+		}
+		b.P(fmt.Sprintf("%s key;", keySolType))
+		b.P("if (has_key) {")
+		b.Indent()
+		b.P("uint64 key_end_pos;")
+		b.P(fmt.Sprintf("(success, key_end_pos, key) = ProtobufLib.decode_%s(new_pos + 1, buf);", decodeType))
+		b.P("if (!success) {")
+		b.Indent()
+		b.P("return (false, pos);")
+		b.Unindent()
+		b.P("}")
+		b.Unindent()
+		b.P("}")
+		if keyType == descriptorpb.FieldDescriptorProto_TYPE_BOOL {
+			b.P("if (has_previous_key && (previous_key || !key)) {")
+		} else {
+			b.P("if (has_previous_key && previous_key >= key) {")
+		}
+	}
+	b.Indent()
+	b.P("return (false, pos); // Keys must be unique and sorted")
+	b.Unindent()
+	b.P("}")
+	if isStringKey {
+		b.P("previous_key_pos = key_pos;")
+		b.P("previous_key_len = key_len;")
+	} else {
+		b.P("previous_key = key;")
+	}
+	b.P("has_previous_key = true;")
+	b.P("pos = new_pos + size;")
+	b.P0()
+
+	b.P("// Continue while the next key belongs to the same map field")
+	b.P("if (pos >= end_pos) {")
+	b.Indent()
+	b.P("break;")
+	b.Unindent()
+	b.P("}")
+	b.P("uint64 next_field_number;")
+	b.P("ProtobufLib.WireType next_wire_type;")
+	b.P("(success, new_pos, next_field_number, next_wire_type) = ProtobufLib.decode_key(pos, buf);")
+	b.P(fmt.Sprintf("if (!success || next_field_number != %d) {", field.GetNumber()))
+	b.Indent()
+	b.P("break;")
+	b.Unindent()
+	b.P("}")
+	b.P(fmt.Sprintf("if (!%sis_minimal_varint(pos, buf) || next_wire_type != ProtobufLib.WireType.LengthDelimited) {", libraryName))
+	b.Indent()
+	b.P("return (false, pos);")
+	b.Unindent()
+	b.P("}")
+	b.P("pos = new_pos;")
+	b.Unindent()
+	b.P("}")
+	b.P("return (true, pos);")
+
+	return nil
+}
@@ -7,29 +7,121 @@ import (
 )
 
 // CodecHelperGenerator handles generation of codec helper functions
-type CodecHelperGenerator struct{}
+type CodecHelperGenerator struct {
+	g *Generator
+}
 
-// NewCodecHelperGenerator creates a new codec helper generator
-func NewCodecHelperGenerator() *CodecHelperGenerator {
-	return &CodecHelperGenerator{}
+// NewCodecHelperGenerator creates a new codec helper generator using the configuration and type mappings of g
+func NewCodecHelperGenerator(g *Generator) *CodecHelperGenerator {
+	return &CodecHelperGenerator{
+		g: g,
+	}
+}
+
+// GenerateSliceHelpers generates the memory slice helpers of the main library, used by decoders to copy bytes and strings
+// and by encoders to write preserved unknown fields.
+// Whole words are copied, or the target's mcopy instruction is used where available.
+func (chg *CodecHelperGenerator) GenerateSliceHelpers(b *WriteableBuffer) {
+	b.P("// Copies length bytes of buf starting at pos into a new bytes value")
+	b.P("function copy_bytes(bytes memory buf, uint64 pos, uint64 length) internal pure returns (bytes memory) {")
+	b.Indent()
+	b.P("bytes memory value = new bytes(length);")
+	b.P("assembly {")
+	b.Indent()
+	b.P("let src := add(add(buf, 32), pos)")
+	b.P("let dst := add(value, 32)")
+	if chg.g.supportsMcopy() {
+		b.P("mcopy(dst, src, length)")
+	} else {
+		b.P("for { let i := 0 } lt(i, length) { i := add(i, 32) } {")
+		b.Indent()
+		b.P("mstore(add(dst, i), mload(add(src, i)))")
+		b.Unindent()
+		b.P("}")
+		b.P("// Clear the bytes copied past the end of the value")
+		b.P("mstore(add(dst, length), 0)")
+	}
+	b.Unindent()
+	b.P("}")
+	b.P("return value;")
+	b.Unindent()
+	b.P("}")
+	b.P0()
+
+	if chg.g.unknownFieldsFlag != unknownFieldsFlagPreserve || chg.g.generateFlag == generateFlagDecoder {
+		return
+	}
+
+	b.P("// Writes length bytes of value starting at value_pos into buf at pos, returning the position after them")
+	b.P("function write_bytes(bytes memory buf, uint64 pos, bytes memory value, uint64 value_pos, uint64 length) internal pure returns (uint64) {")
+	b.Indent()
+	b.P("assembly {")
+	b.Indent()
+	b.P("let src := add(add(value, 32), value_pos)")
+	b.P("let dst := add(add(buf, 32), pos)")
+	if chg.g.supportsMcopy() {
+		b.P("mcopy(dst, src, length)")
+	} else {
+		b.P("let i := 0")
+		b.P("for { } lt(add(i, 31), length) { i := add(i, 32) } {")
+		b.Indent()
+		b.P("mstore(add(dst, i), mload(add(src, i)))")
+		b.Unindent()
+		b.P("}")
+		b.P("// Merge the last partial word with the bytes of buf following it, which may belong to other values")
+		b.P("if lt(i, length) {")
+		b.Indent()
+		b.P("let mask := sub(shl(mul(sub(32, sub(length, i)), 8), 1), 1)")
+		b.P("mstore(add(dst, i), or(and(mload(add(src, i)), not(mask)), and(mload(add(dst, i)), mask)))")
+		b.Unindent()
+		b.P("}")
+	}
+	b.Unindent()
+	b.P("}")
+	b.P("return pos + length;")
+	b.Unindent()
+	b.P("}")
+	b.P0()
 }
 
 // GenerateCodecHelpers generates helper functions for codec libraries
-func (chg *CodecHelperGenerator) GenerateCodecHelpers(structName string, fields []*descriptorpb.FieldDescriptorProto, fieldNameMap map[int32]string, b *WriteableBuffer) error {
+func (chg *CodecHelperGenerator) GenerateCodecHelpers(structName string, descriptor *descriptorpb.DescriptorProto, fieldNameMap map[int32]string, b *WriteableBuffer) error {
+	fields := descriptor.GetField()
+
 	// Generate check_key function
-	chg.generateCheckKeyFunction(structName, fields, b)
+	chg.generateCheckKeyFunction(structName, fields, descriptor.GetReservedRange(), b)
 
 	// Generate decode_field function
-	chg.generateDecodeFieldFunction(structName, fields, fieldNameMap, b)
+	err := chg.generateDecodeFieldFunction(structName, descriptor, fieldNameMap, b)
+	if err != nil {
+		return err
+	}
+
+	// Generate unknown field helpers for forward-compatible decoding
+	if chg.g.unknownFieldsFlag != unknownFieldsFlagReject {
+		chg.generateIsKnownFieldFunction(fields, b)
+		chg.generateSkipFieldFunction(b)
+	}
 
 	return nil
 }
 
-// generateCheckKeyFunction generates the check_key function for wire type validation
-func (chg *CodecHelperGenerator) generateCheckKeyFunction(structName string, fields []*descriptorpb.FieldDescriptorProto, b *WriteableBuffer) {
+// generateCheckKeyFunction generates the check_key function for wire type validation.
+// Field numbers in gaps between declared fields fall through to the final rejection,
+// reserved ranges are rejected explicitly so sparse schemas stay readable.
+func (chg *CodecHelperGenerator) generateCheckKeyFunction(structName string, fields []*descriptorpb.FieldDescriptorProto, reservedRanges []*descriptorpb.DescriptorProto_ReservedRange, b *WriteableBuffer) {
 	b.P("function check_key(uint64 field_number, ProtobufLib.WireType wire_type) internal pure returns (bool) {")
 	b.Indent()
 
+	// Reject reserved field numbers (reserved range ends are exclusive)
+	for _, reservedRange := range reservedRanges {
+		b.P(fmt.Sprintf("if (field_number >= %d && field_number < %d) {", reservedRange.GetStart(), reservedRange.GetEnd()))
+		b.Indent()
+		b.P("return false; // Reserved field number")
+		b.Unindent()
+		b.P("}")
+	}
+
 	// Generate wire type checks for each field
 	for _, field := range fields {
 		fieldNumber := field.GetNumber()
@@ -38,6 +130,14 @@ func (chg *CodecHelperGenerator) generateCheckKeyFunction(structName string, fie
 		b.P(fmt.Sprintf("if (field_number == %d) {", fieldNumber))
 		b.Indent()
 
+		// Packed repeated fields are always length-delimited
+		if isFieldRepeated(field) && chg.g.isFieldPacked(field) {
+			b.P("return wire_type == ProtobufLib.WireType.LengthDelimited;")
+			b.Unindent()
+			b.P("}")
+			continue
+		}
+
 		// Check wire type based on field type
 		switch fieldType {
 		case descriptorpb.FieldDescriptorProto_TYPE_INT32,
@@ -75,30 +175,529 @@ func (chg *CodecHelperGenerator) generateCheckKeyFunction(structName string, fie
 	b.P0()
 }
 
+// generateIsKnownFieldFunction generates the is_known_field function used to detect
+// fields added by newer versions of the schema
+func (chg *CodecHelperGenerator) generateIsKnownFieldFunction(fields []*descriptorpb.FieldDescriptorProto, b *WriteableBuffer) {
+	b.P("function is_known_field(uint64 field_number) internal pure returns (bool) {")
+	b.Indent()
+
+	for _, field := range fields {
+		b.P(fmt.Sprintf("if (field_number == %d) {", field.GetNumber()))
+		b.Indent()
+		b.P("return true;")
+		b.Unindent()
+		b.P("}")
+	}
+
+	b.P("return false;")
+	b.Unindent()
+	b.P("}")
+	b.P0()
+}
+
+// generateSkipFieldFunction generates the skip_field function that advances past
+// a field value based on its wire type alone
+func (chg *CodecHelperGenerator) generateSkipFieldFunction(b *WriteableBuffer) {
+	b.P("function skip_field(uint64 pos, bytes memory buf, ProtobufLib.WireType wire_type) internal pure returns (bool, uint64) {")
+	b.Indent()
+	b.P("bool success;")
+	b.P("uint64 new_pos;")
+	b.P("if (wire_type == ProtobufLib.WireType.Varint) {")
+	b.Indent()
+	b.P("uint64 value;")
+	b.P("(success, new_pos, value) = ProtobufLib.decode_varint(pos, buf);")
+	b.P("if (!success) {")
+	b.Indent()
+	b.P("return (false, pos);")
+	b.Unindent()
+	b.P("}")
+	b.Unindent()
+	b.P("} else if (wire_type == ProtobufLib.WireType.Bits64) {")
+	b.Indent()
+	b.P("new_pos = pos + 8;")
+	b.Unindent()
+	b.P("} else if (wire_type == ProtobufLib.WireType.Bits32) {")
+	b.Indent()
+	b.P("new_pos = pos + 4;")
+	b.Unindent()
+	b.P("} else if (wire_type == ProtobufLib.WireType.LengthDelimited) {")
+	b.Indent()
+	b.P("uint64 size;")
+	b.P("(success, new_pos, size) = ProtobufLib.decode_length_delimited(pos, buf);")
+	b.P("if (!success) {")
+	b.Indent()
+	b.P("return (false, pos);")
+	b.Unindent()
+	b.P("}")
+	b.P("new_pos += size;")
+	b.Unindent()
+	b.P("} else {")
+	b.Indent()
+	b.P("return (false, pos); // Groups are not supported")
+	b.Unindent()
+	b.P("}")
+	b.P("if (new_pos > buf.length) {")
+	b.Indent()
+	b.P("return (false, pos);")
+	b.Unindent()
+	b.P("}")
+	b.P("return (true, new_pos);")
+	b.Unindent()
+	b.P("}")
+	b.P0()
+}
+
 // generateDecodeFieldFunction generates the decode_field function for field decoding
-func (chg *CodecHelperGenerator) generateDecodeFieldFunction(structName string, fields []*descriptorpb.FieldDescriptorProto, fieldNameMap map[int32]string, b *WriteableBuffer) {
-	b.P(fmt.Sprintf("function decode_field(uint64 pos, bytes memory buf, uint64 len, uint64 field_number, %s memory instance) internal pure returns (bool, uint64) {", structName))
+func (chg *CodecHelperGenerator) generateDecodeFieldFunction(structName string, descriptor *descriptorpb.DescriptorProto, fieldNameMap map[int32]string, b *WriteableBuffer) error {
+	if chg.g.optimizeFlag == optimizeFlagGas {
+		return chg.generateOptimizedDecodeFieldFunction(structName, descriptor, fieldNameMap, b)
+	}
+
+	b.P(fmt.Sprintf("function decode_field(uint64 pos, bytes memory buf, uint64 len, uint64 field_number, %s memory instance, uint64 depth) internal pure returns (bool, uint64) {", structName))
 	b.Indent()
 
 	// Generate field decoding for each field
+	for _, field := range descriptor.GetField() {
+		fieldNumber := field.GetNumber()
+
+		b.P(fmt.Sprintf("if (field_number == %d) {", fieldNumber))
+		b.Indent()
+		err := chg.generateFieldDecodingBody(field, descriptor, fieldNameMap[fieldNumber], structName, b)
+		if err != nil {
+			return err
+		}
+		b.Unindent()
+		b.P("}")
+	}
+
+	b.P("return (false, pos); // Unknown field number")
+	b.Unindent()
+	b.P("}")
+	b.P0()
+
+	return nil
+}
+
+// generateOptimizedDecodeFieldFunction generates the decode_field function of optimize=gas codecs.
+// Each field gets its own decode_<n> function, found with a binary search over the field numbers.
+func (chg *CodecHelperGenerator) generateOptimizedDecodeFieldFunction(structName string, descriptor *descriptorpb.DescriptorProto, fieldNameMap map[int32]string, b *WriteableBuffer) error {
+	fields := descriptor.GetField()
+
+	NewFastPathGenerator(chg.g).GenerateDispatch(structName, fields, b)
+
 	for _, field := range fields {
 		fieldNumber := field.GetNumber()
 		fieldName := fieldNameMap[fieldNumber]
 
-		b.P(fmt.Sprintf("if (field_number == %d) {", fieldNumber))
+		b.P(fmt.Sprintf("// %s.%s", structName, fieldName))
+		b.P(fmt.Sprintf("function decode_%d(uint64 pos, bytes memory buf, uint64 len, %s memory instance, uint64 depth) internal pure returns (bool, uint64) {", fieldNumber, structName))
 		b.Indent()
+		err := chg.generateFieldDecodingBody(field, descriptor, fieldName, structName, b)
+		if err != nil {
+			return err
+		}
+		b.Unindent()
+		b.P("}")
+		b.P0()
+	}
 
-		// Generate decoding logic based on field type
-		chg.generateFieldDecoding(field, fieldName, structName, b)
+	return nil
+}
+
+// generateFieldDecodingBody generates the decoding logic of a field, returning success and the new position
+func (chg *CodecHelperGenerator) generateFieldDecodingBody(field *descriptorpb.FieldDescriptorProto, descriptor *descriptorpb.DescriptorProto, fieldName string, structName string, b *WriteableBuffer) error {
+	if chg.g.hasFieldPresence(field) {
+		// Recording presence up front is safe, as a field failing to decode fails the whole message
+		b.P(fmt.Sprintf("instance.%s = true;", presenceMemberName(fieldName)))
+	}
+	if isFieldRepeated(field) && chg.g.isFieldPacked(field) {
+		return chg.generatePackedFieldDecoding(field, fieldName, structName, b)
+	}
+	if chg.g.isFieldExpanded(field) {
+		return chg.generateExpandedFieldDecoding(field, fieldName, structName, b)
+	}
+	if isEmbeddedMessageField(field) {
+		return chg.generateMessageFieldDecoding(field, descriptor, fieldName, structName, b)
+	}
+	solType, fixedSize, err := fieldTypeOverride(field)
+	if err != nil {
+		return err
+	}
+	if len(solType) > 0 {
+		chg.generateOverriddenFieldDecoding(field, solType, fixedSize, fieldName, structName, b)
+		return nil
+	}
+	chg.generateFieldDecoding(field, fieldName, structName, b)
+	return nil
+}
 
+// generateOverriddenFieldDecoding generates the decoding logic for a string or bytes field with an overridden Solidity type.
+// Fixed size types reject values of any other length, as they cannot hold them, and decimal strings are parsed.
+func (chg *CodecHelperGenerator) generateOverriddenFieldDecoding(field *descriptorpb.FieldDescriptorProto, solType string, fixedSize int, fieldName string, structName string, b *WriteableBuffer) {
+	b.P("bool success;")
+	b.P("uint64 new_pos;")
+	b.P("uint64 length;")
+	chg.generateMinimalVarintCheck("pos", structName, b)
+	b.P("(success, new_pos, length) = ProtobufLib.decode_bytes(pos, buf);")
+	b.P("if (!success) {")
+	b.Indent()
+	b.P("return (false, pos);")
+	b.Unindent()
+	b.P("}")
+
+	if isDecimalIntegerField(field) {
+		b.P("bool valid;")
+		b.P(fmt.Sprintf("%s value;", solType))
+		b.P(fmt.Sprintf("(valid, value) = %sdecode_decimal_%s(buf, new_pos, length);", libraryPrefix(structName), solType))
+		b.P("if (!valid) {")
+		b.Indent()
+		b.P(fmt.Sprintf("return (false, pos); // Not a decimal %s", solType))
 		b.Unindent()
 		b.P("}")
+		// "0" is a non-empty string, so it is accepted like any other value although encoders omit zero
+		if chg.g.strictCanonical {
+			// Zero is a single digit, while longer values cannot start with one
+			b.P("if (length > 1) {")
+			b.Indent()
+			NewDecimalGenerator(chg.g).GenerateLeadingZeroCheck(solType, "new_pos", "return (false, pos); // Leading zeros must be omitted", b)
+			b.Unindent()
+			b.P("}")
+		}
+		b.P(fmt.Sprintf("instance.%s = value;", fieldName))
+		b.P("pos = new_pos + length;")
+		b.P("return (true, pos);")
+		return
 	}
 
-	b.P("return (false, pos); // Unknown field number")
+	if fixedSize == 0 {
+		// A string field decoded as bytes, or a bytes field kept as bytes
+		chg.generateDefaultValueCheck(field, "length == 0", b)
+		chg.generateBytesCopy("value", "new_pos", "length", structName, b)
+		b.P(fmt.Sprintf("instance.%s = value;", fieldName))
+		b.P("pos = new_pos + length;")
+		b.P("return (true, pos);")
+		return
+	}
+
+	b.P(fmt.Sprintf("if (length != %d) {", fixedSize))
+	b.Indent()
+	b.P(fmt.Sprintf("return (false, pos); // %s values must be exactly %d bytes", solType, fixedSize))
 	b.Unindent()
 	b.P("}")
+	// The length check guarantees the value is within the buffer, and the conversion drops whatever follows it in the word
+	b.P("bytes32 word;")
+	b.P("assembly {")
+	b.Indent()
+	b.P("word := mload(add(add(buf, 32), new_pos))")
+	b.Unindent()
+	b.P("}")
+	// Zero-filled bytes are not the protobuf default (empty bytes), so they are accepted although encoders omit zero
+	b.P(fmt.Sprintf("%s value = %s;", solType, fixedValueFromWord(solType, fixedSize, "word")))
+	b.P(fmt.Sprintf("instance.%s = value;", fieldName))
+	b.P("pos = new_pos + length;")
+	b.P("return (true, pos);")
+}
+
+// generatePackedFieldDecoding generates the decoding logic for a packed repeated field.
+// Elements are counted before decoding since memory arrays cannot grow.
+func (chg *CodecHelperGenerator) generatePackedFieldDecoding(field *descriptorpb.FieldDescriptorProto, fieldName string, structName string, b *WriteableBuffer) error {
+	fieldType := field.GetType()
+
+	var elementType string
+	var err error
+	if fieldType == descriptorpb.FieldDescriptorProto_TYPE_ENUM {
+		elementType, err = chg.g.getSolTypeName(field)
+	} else {
+		elementType, err = typeToSol(fieldType)
+	}
+	if err != nil {
+		return fmt.Errorf("%v: %s.%s", err, structName, fieldName)
+	}
+
+	b.P("bool success;")
+	b.P("uint64 new_pos;")
+	b.P("uint64 size;")
+	chg.generateMinimalVarintCheck("pos", structName, b)
+	b.P("(success, new_pos, size) = ProtobufLib.decode_length_delimited(pos, buf);")
+	b.P("if (!success) {")
+	b.Indent()
+	b.P("return (false, pos);")
+	b.Unindent()
+	b.P("}")
+	if !chg.g.allowEmptyPackedArrays {
+		b.P("// Empty packed arrays must be omitted instead of encoded")
+		b.P("if (size == 0) {")
+		b.Indent()
+		b.P("return (false, pos);")
+		b.Unindent()
+		b.P("}")
+	}
+	b.P("uint64 end_pos = new_pos + size;")
+	b.P("if (end_pos > buf.length) {")
+	b.Indent()
+	b.P("return (false, pos);")
+	b.Unindent()
+	b.P("}")
+	b.P0()
+
+	// Count the elements in the packed payload
+	b.P("uint64 count;")
+	switch fieldType {
+	case descriptorpb.FieldDescriptorProto_TYPE_FIXED32,
+		descriptorpb.FieldDescriptorProto_TYPE_SFIXED32,
+		descriptorpb.FieldDescriptorProto_TYPE_FLOAT:
+		b.P("if (size % 4 != 0) {")
+		b.Indent()
+		b.P("return (false, pos);")
+		b.Unindent()
+		b.P("}")
+		b.P("count = size / 4;")
+	case descriptorpb.FieldDescriptorProto_TYPE_FIXED64,
+		descriptorpb.FieldDescriptorProto_TYPE_SFIXED64,
+		descriptorpb.FieldDescriptorProto_TYPE_DOUBLE:
+		b.P("if (size % 8 != 0) {")
+		b.Indent()
+		b.P("return (false, pos);")
+		b.Unindent()
+		b.P("}")
+		b.P("count = size / 8;")
+	default:
+		b.P("// Each varint ends with a byte that has the continuation bit cleared")
+		b.P("for (uint64 i = new_pos; i < end_pos; i++) {")
+		b.Indent()
+		b.P("if (uint8(buf[i]) & 0x80 == 0) {")
+		b.Indent()
+		b.P("count++;")
+		b.Unindent()
+		b.P("}")
+		b.Unindent()
+		b.P("}")
+	}
 	b.P0()
+
+	// Decode the elements
+	b.P(fmt.Sprintf("%s[] memory values = new %s[](count);", elementType, elementType))
+	b.P("for (uint64 i = 0; i < count; i++) {")
+	b.Indent()
+	switch fieldType {
+	case descriptorpb.FieldDescriptorProto_TYPE_ENUM:
+		b.P("int32 enum_value;")
+		chg.generateMinimalVarintCheck("new_pos", structName, b)
+		b.P("(success, new_pos, enum_value) = ProtobufLib.decode_enum(new_pos, buf);")
+		enumCheck := "enum_value < 0"
+		if enumMax, ok := chg.g.enumMaxes[elementType]; ok {
+			enumCheck = fmt.Sprintf("enum_value < 0 || enum_value > %d", enumMax)
+		}
+		b.P(fmt.Sprintf("if (!success || %s) {", enumCheck))
+		b.Indent()
+		b.P("return (false, pos);")
+		b.Unindent()
+		b.P("}")
+		b.P(fmt.Sprintf("values[i] = %s(uint32(enum_value));", elementType))
+	default:
+		decodeType, err := typeToDecodeSol(fieldType)
+		if err != nil {
+			return fmt.Errorf("%v: %s.%s", err, structName, fieldName)
+		}
+		decodeFunction := "ProtobufLib.decode_" + decodeType
+		if fieldType == descriptorpb.FieldDescriptorProto_TYPE_FLOAT || fieldType == descriptorpb.FieldDescriptorProto_TYPE_DOUBLE {
+			// Fixed-point helpers live in the main library
+			decodeFunction = libraryPrefix(structName) + "decode_" + decodeType
+		} else if wireType, _ := wireTypeNumber(fieldType); wireType == 0 {
+			chg.generateMinimalVarintCheck("new_pos", structName, b)
+		}
+		b.P(fmt.Sprintf("(success, new_pos, values[i]) = %s(new_pos, buf);", decodeFunction))
+		b.P("if (!success) {")
+		b.Indent()
+		b.P("return (false, pos);")
+		b.Unindent()
+		b.P("}")
+	}
+	b.Unindent()
+	b.P("}")
+	b.P("if (new_pos != end_pos) {")
+	b.Indent()
+	b.P("return (false, pos);")
+	b.Unindent()
+	b.P("}")
+	b.P(fmt.Sprintf("instance.%s = values;", fieldName))
+	b.P("pos = new_pos;")
+	b.P("return (true, pos);")
+
+	return nil
+}
+
+// generateExpandedFieldDecoding generates the decoding logic for an element of an expanded repeated numeric field.
+// Like repeated message fields, the array grows by one element per occurrence.
+func (chg *CodecHelperGenerator) generateExpandedFieldDecoding(field *descriptorpb.FieldDescriptorProto, fieldName string, structName string, b *WriteableBuffer) error {
+	fieldType := field.GetType()
+
+	var elementType string
+	var err error
+	if fieldType == descriptorpb.FieldDescriptorProto_TYPE_ENUM {
+		elementType, err = chg.g.getSolTypeName(field)
+	} else {
+		elementType, err = typeToSol(fieldType)
+	}
+	if err != nil {
+		return fmt.Errorf("%v: %s.%s", err, structName, fieldName)
+	}
+
+	b.P("bool success;")
+	b.P("uint64 new_pos;")
+	b.P(fmt.Sprintf("%s value;", elementType))
+	switch fieldType {
+	case descriptorpb.FieldDescriptorProto_TYPE_ENUM:
+		b.P("int32 enum_value;")
+		chg.generateMinimalVarintCheck("pos", structName, b)
+		b.P("(success, new_pos, enum_value) = ProtobufLib.decode_enum(pos, buf);")
+		enumCheck := "enum_value < 0"
+		if enumMax, ok := chg.g.enumMaxes[elementType]; ok {
+			enumCheck = fmt.Sprintf("enum_value < 0 || enum_value > %d", enumMax)
+		}
+		b.P(fmt.Sprintf("if (!success || %s) {", enumCheck))
+		b.Indent()
+		b.P("return (false, pos);")
+		b.Unindent()
+		b.P("}")
+		b.P(fmt.Sprintf("value = %s(uint32(enum_value));", elementType))
+	default:
+		decodeType, err := typeToDecodeSol(fieldType)
+		if err != nil {
+			return fmt.Errorf("%v: %s.%s", err, structName, fieldName)
+		}
+		decodeFunction := "ProtobufLib.decode_" + decodeType
+		if fieldType == descriptorpb.FieldDescriptorProto_TYPE_FLOAT || fieldType == descriptorpb.FieldDescriptorProto_TYPE_DOUBLE {
+			// Fixed-point helpers live in the main library
+			decodeFunction = libraryPrefix(structName) + "decode_" + decodeType
+		} else if wireType, _ := wireTypeNumber(fieldType); wireType == 0 {
+			chg.generateMinimalVarintCheck("pos", structName, b)
+		}
+		b.P(fmt.Sprintf("(success, new_pos, value) = %s(pos, buf);", decodeFunction))
+		b.P("if (!success) {")
+		b.Indent()
+		b.P("return (false, pos);")
+		b.Unindent()
+		b.P("}")
+	}
+
+	b.P(fmt.Sprintf("%s[] memory values = new %s[](instance.%s.length + 1);", elementType, elementType, fieldName))
+	b.P(fmt.Sprintf("for (uint256 i = 0; i < instance.%s.length; i++) {", fieldName))
+	b.Indent()
+	b.P(fmt.Sprintf("values[i] = instance.%s[i];", fieldName))
+	b.Unindent()
+	b.P("}")
+	b.P(fmt.Sprintf("values[instance.%s.length] = value;", fieldName))
+	b.P(fmt.Sprintf("instance.%s = values;", fieldName))
+	b.P("pos = new_pos;")
+	b.P("return (true, pos);")
+
+	return nil
+}
+
+// generateMessageFieldDecoding generates the decoding logic for an embedded message field.
+// The nesting depth is passed on so that the nested decoder can enforce max_depth.
+// Recursive fields are still decoded to validate them, but only their encoding is kept.
+// Repeated fields grow their array by one element per occurrence.
+func (chg *CodecHelperGenerator) generateMessageFieldDecoding(field *descriptorpb.FieldDescriptorProto, descriptor *descriptorpb.DescriptorProto, fieldName string, structName string, b *WriteableBuffer) error {
+	var typeName string
+	if chg.g.isMapField(field, descriptor) {
+		// Map entries are decoded into their wrapper messages in the main library
+		typeName = libraryPrefix(structName) + CreateMapEntryWrapperName(fieldName)
+	} else {
+		var err error
+		typeName, err = chg.g.getSolTypeName(field)
+		if err != nil {
+			return err
+		}
+	}
+
+	b.P("bool success;")
+	b.P("uint64 new_pos;")
+	b.P("uint64 size;")
+	chg.generateMinimalVarintCheck("pos", structName, b)
+	b.P("(success, new_pos, size) = ProtobufLib.decode_embedded_message(pos, buf);")
+	b.P("if (!success) {")
+	b.Indent()
+	b.P("return (false, pos);")
+	b.Unindent()
+	b.P("}")
+	b.P(fmt.Sprintf("%s memory value;", typeName))
+	b.P("uint64 end_pos;")
+	b.P(fmt.Sprintf("(success, end_pos, value) = %s.decode(new_pos, buf, size, depth + 1);", CodecLibraryName(typeName)))
+	b.P("if (!success || end_pos != new_pos + size) {")
+	b.Indent()
+	b.P("return (false, pos);")
+	b.Unindent()
+	b.P("}")
+
+	switch {
+	case isFieldRepeated(field):
+		b.P(fmt.Sprintf("%s[] memory values = new %s[](instance.%s.length + 1);", typeName, typeName, fieldName))
+		b.P(fmt.Sprintf("for (uint256 i = 0; i < instance.%s.length; i++) {", fieldName))
+		b.Indent()
+		b.P(fmt.Sprintf("values[i] = instance.%s[i];", fieldName))
+		b.Unindent()
+		b.P("}")
+		b.P(fmt.Sprintf("values[instance.%s.length] = value;", fieldName))
+		b.P(fmt.Sprintf("instance.%s = values;", fieldName))
+	case chg.g.isRecursiveField(field):
+		b.P("// Keep the encoded message, since the struct cannot contain itself")
+		chg.generateBytesCopy("encoded", "new_pos", "size", structName, b)
+		b.P(fmt.Sprintf("instance.%s = encoded;", fieldName))
+	default:
+		b.P(fmt.Sprintf("instance.%s = value;", fieldName))
+	}
+	b.P("pos = end_pos;")
+	b.P("return (true, pos);")
+
+	return nil
+}
+
+// generateMinimalVarintCheck generates a check rejecting overlong varints at posVar when strict_canonical is enabled
+func (chg *CodecHelperGenerator) generateMinimalVarintCheck(posVar string, structName string, b *WriteableBuffer) {
+	if !chg.g.strictCanonical {
+		return
+	}
+	b.P(fmt.Sprintf("if (!%sis_minimal_varint(%s, buf)) {", libraryPrefix(structName), posVar))
+	b.Indent()
+	b.P("return (false, pos); // Non-minimal varint")
+	b.Unindent()
+	b.P("}")
+}
+
+// generateDefaultValueCheck generates a check rejecting explicitly encoded default values when strict_canonical is enabled.
+// Fields tracking their presence are encoded whenever set, so their default values are accepted.
+func (chg *CodecHelperGenerator) generateDefaultValueCheck(field *descriptorpb.FieldDescriptorProto, condition string, b *WriteableBuffer) {
+	if !chg.g.strictCanonical || chg.g.hasFieldPresence(field) {
+		return
+	}
+	b.P(fmt.Sprintf("if (%s) {", condition))
+	b.Indent()
+	b.P("return (false, pos); // Default values must be omitted")
+	b.Unindent()
+	b.P("}")
+}
+
+// generateVarintDecoding generates decoding of a varint into value with the given ProtobufLib function.
+// In optimize=gas mode single byte varints are read inline first.
+func (chg *CodecHelperGenerator) generateVarintDecoding(decodeFunction string, fastCondition string, fastValue string, structName string, b *WriteableBuffer) {
+	if chg.g.optimizeFlag == optimizeFlagGas {
+		NewFastPathGenerator(chg.g).GenerateVarintDecoding(decodeFunction, fastCondition, fastValue, structName, chg, b)
+		return
+	}
+	chg.generateMinimalVarintCheck("pos", structName, b)
+	b.P(fmt.Sprintf("(success, new_pos, value) = %s(pos, buf);", decodeFunction))
+	b.P("if (!success) {")
+	b.Indent()
+	b.P("return (false, pos);")
+	b.Unindent()
+	b.P("}")
+}
+
+// generateBytesCopy generates a copy of length bytes of buf starting at posVar into a new bytes variable
+func (chg *CodecHelperGenerator) generateBytesCopy(variable string, posVar string, lengthVar string, structName string, b *WriteableBuffer) {
+	b.P(fmt.Sprintf("bytes memory %s = %scopy_bytes(buf, %s, %s);", variable, libraryPrefix(structName), posVar, lengthVar))
 }
 
 // generateFieldDecoding generates the decoding logic for a specific field
@@ -111,12 +710,20 @@ func (chg *CodecHelperGenerator) generateFieldDecoding(field *descriptorpb.Field
 		b.P("bool success;")
 		b.P("uint64 new_pos;")
 		b.P("string memory value;")
-		b.P(fmt.Sprintf("(success, new_pos, value) = ProtobufLib.decode_string(pos, buf);"))
+		chg.generateMinimalVarintCheck("pos", structName, b)
+		// Copy the string with the slice helper instead of byte by byte in ProtobufLib
+		b.P("uint64 length;")
+		b.P("(success, new_pos, length) = ProtobufLib.decode_bytes(pos, buf);")
 		b.P("if (!success) {")
 		b.Indent()
 		b.P("return (false, pos);")
 		b.Unindent()
 		b.P("}")
+		b.P(fmt.Sprintf("value = string(%scopy_bytes(buf, new_pos, length));", libraryPrefix(structName)))
+		b.P("new_pos += length;")
+		if !isRepeated {
+			chg.generateDefaultValueCheck(field, "bytes(value).length == 0", b)
+		}
 		if isRepeated {
 			// For repeated fields, we need to append to the array
 			// TODO: Implement proper repeated field handling - for now, just a placeholder
@@ -132,12 +739,8 @@ func (chg *CodecHelperGenerator) generateFieldDecoding(field *descriptorpb.Field
 		b.P("bool success;")
 		b.P("uint64 new_pos;")
 		b.P("uint32 value;")
-		b.P(fmt.Sprintf("(success, new_pos, value) = ProtobufLib.decode_uint32(pos, buf);"))
-		b.P("if (!success) {")
-		b.Indent()
-		b.P("return (false, pos);")
-		b.Unindent()
-		b.P("}")
+		chg.generateVarintDecoding("ProtobufLib.decode_uint32", "success", "uint32(single_byte)", structName, b)
+		chg.generateDefaultValueCheck(field, "value == 0", b)
 		b.P(fmt.Sprintf("instance.%s = value;", fieldName))
 		b.P("pos = new_pos;")
 		b.P("return (true, pos);")
@@ -146,12 +749,8 @@ func (chg *CodecHelperGenerator) generateFieldDecoding(field *descriptorpb.Field
 		b.P("bool success;")
 		b.P("uint64 new_pos;")
 		b.P("int32 value;")
-		b.P(fmt.Sprintf("(success, new_pos, value) = ProtobufLib.decode_int32(pos, buf);"))
-		b.P("if (!success) {")
-		b.Indent()
-		b.P("return (false, pos);")
-		b.Unindent()
-		b.P("}")
+		chg.generateVarintDecoding("ProtobufLib.decode_int32", "success", "int32(uint32(single_byte))", structName, b)
+		chg.generateDefaultValueCheck(field, "value == 0", b)
 		b.P(fmt.Sprintf("instance.%s = value;", fieldName))
 		b.P("pos = new_pos;")
 		b.P("return (true, pos);")
@@ -160,12 +759,8 @@ func (chg *CodecHelperGenerator) generateFieldDecoding(field *descriptorpb.Field
 		b.P("bool success;")
 		b.P("uint64 new_pos;")
 		b.P("bool value;")
-		b.P(fmt.Sprintf("(success, new_pos, value) = ProtobufLib.decode_bool(pos, buf);"))
-		b.P("if (!success) {")
-		b.Indent()
-		b.P("return (false, pos);")
-		b.Unindent()
-		b.P("}")
+		chg.generateVarintDecoding("ProtobufLib.decode_bool", "success && single_byte <= 1", "single_byte == 1", structName, b)
+		chg.generateDefaultValueCheck(field, "!value", b)
 		b.P(fmt.Sprintf("instance.%s = value;", fieldName))
 		b.P("pos = new_pos;")
 		b.P("return (true, pos);")
@@ -174,18 +769,17 @@ func (chg *CodecHelperGenerator) generateFieldDecoding(field *descriptorpb.Field
 		b.P("bool success;")
 		b.P("uint64 new_pos;")
 		b.P("uint64 length;")
+		chg.generateMinimalVarintCheck("pos", structName, b)
 		b.P(fmt.Sprintf("(success, new_pos, length) = ProtobufLib.decode_bytes(pos, buf);"))
 		b.P("if (!success) {")
 		b.Indent()
 		b.P("return (false, pos);")
 		b.Unindent()
 		b.P("}")
-		b.P("bytes memory value = new bytes(length);")
-		b.P("for (uint64 i = 0; i < length; i++) {")
-		b.Indent()
-		b.P("value[i] = buf[new_pos + i];")
-		b.Unindent()
-		b.P("}")
+		if !isRepeated {
+			chg.generateDefaultValueCheck(field, "length == 0", b)
+		}
+		chg.generateBytesCopy("value", "new_pos", "length", structName, b)
 		if isRepeated {
 			// For repeated fields, we need to append to the array
 			// TODO: Implement proper repeated field handling - for now, just a placeholder
@@ -207,6 +801,7 @@ func (chg *CodecHelperGenerator) generateFieldDecoding(field *descriptorpb.Field
 		b.P("return (false, pos);")
 		b.Unindent()
 		b.P("}")
+		chg.generateDefaultValueCheck(field, "value == 0", b)
 		b.P(fmt.Sprintf("instance.%s = value;", fieldName))
 		b.P("pos = new_pos;")
 		b.P("return (true, pos);")
@@ -0,0 +1,173 @@
+package generator
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// DecimalGenerator handles generation of conversions between decimal strings and 256 bit integers
+type DecimalGenerator struct {
+	g *Generator
+}
+
+// NewDecimalGenerator creates a new decimal conversion generator
+func NewDecimalGenerator(g *Generator) *DecimalGenerator {
+	return &DecimalGenerator{
+		g: g,
+	}
+}
+
+// fileHasDecimalIntegerFields checks if any message of a file has a string field set with (sol.uint256) or (sol.int256)
+func fileHasDecimalIntegerFields(protoFile *descriptorpb.FileDescriptorProto) bool {
+	var hasDecimal func(msg *descriptorpb.DescriptorProto) bool
+	hasDecimal = func(msg *descriptorpb.DescriptorProto) bool {
+		for _, field := range msg.GetField() {
+			if isDecimalIntegerField(field) {
+				return true
+			}
+		}
+		for _, nested := range msg.GetNestedType() {
+			if hasDecimal(nested) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, msg := range protoFile.GetMessageType() {
+		if hasDecimal(msg) {
+			return true
+		}
+	}
+	return false
+}
+
+// GenerateDecimalHelpers generates the main library helpers parsing and formatting decimal integers.
+// Parsing fails on empty strings, characters other than digits and values out of range.
+func (dg *DecimalGenerator) GenerateDecimalHelpers(b *WriteableBuffer) {
+	b.P("// Parses length bytes of buf starting at pos as the decimal digits of a uint256")
+	b.P("function decode_decimal_uint256(bytes memory buf, uint64 pos, uint64 length) internal pure returns (bool, uint256) {")
+	b.Indent()
+	b.P("if (length == 0) {")
+	b.Indent()
+	b.P("return (false, 0);")
+	b.Unindent()
+	b.P("}")
+	b.P("uint256 value = 0;")
+	b.P("for (uint64 i = pos; i < pos + length; i++) {")
+	b.Indent()
+	b.P("uint8 digit = uint8(buf[i]);")
+	b.P("if (digit < 48 || digit > 57) {")
+	b.Indent()
+	b.P("return (false, 0);")
+	b.Unindent()
+	b.P("}")
+	b.P("digit -= 48;")
+	b.P("if (value > (~uint256(0) - digit) / 10) {")
+	b.Indent()
+	b.P("return (false, 0); // Overflow")
+	b.Unindent()
+	b.P("}")
+	b.P("value = value * 10 + digit;")
+	b.Unindent()
+	b.P("}")
+	b.P("return (true, value);")
+	b.Unindent()
+	b.P("}")
+	b.P0()
+
+	b.P("// Parses length bytes of buf starting at pos as an optional minus sign followed by the decimal digits of an int256")
+	b.P("function decode_decimal_int256(bytes memory buf, uint64 pos, uint64 length) internal pure returns (bool, int256) {")
+	b.Indent()
+	b.P("bool negative = length > 0 && buf[pos] == \"-\";")
+	b.P("if (negative) {")
+	b.Indent()
+	b.P("pos += 1;")
+	b.P("length -= 1;")
+	b.Unindent()
+	b.P("}")
+	b.P("bool success;")
+	b.P("uint256 magnitude;")
+	b.P("(success, magnitude) = decode_decimal_uint256(buf, pos, length);")
+	b.P("if (!success) {")
+	b.Indent()
+	b.P("return (false, 0);")
+	b.Unindent()
+	b.P("}")
+	b.P("uint256 max = ~uint256(0) >> 1;")
+	b.P("if (!negative) {")
+	b.Indent()
+	b.P("if (magnitude > max) {")
+	b.Indent()
+	b.P("return (false, 0); // Overflow")
+	b.Unindent()
+	b.P("}")
+	b.P("return (true, int256(magnitude));")
+	b.Unindent()
+	b.P("}")
+	b.P("// The minimum is one below the negated maximum")
+	b.P("if (magnitude > max + 1) {")
+	b.Indent()
+	b.P("return (false, 0); // Overflow")
+	b.Unindent()
+	b.P("}")
+	b.P("if (magnitude == 0) {")
+	b.Indent()
+	b.P("return (true, 0);")
+	b.Unindent()
+	b.P("}")
+	b.P("// Two's complement negation, which does not overflow for the minimum")
+	b.P("return (true, int256(~magnitude + 1));")
+	b.Unindent()
+	b.P("}")
+	b.P0()
+
+	b.P("// Returns the decimal digits of value, without leading zeros")
+	b.P("function encode_decimal_uint256(uint256 value) internal pure returns (bytes memory) {")
+	b.Indent()
+	b.P("uint256 length = 1;")
+	b.P("for (uint256 rest = value / 10; rest > 0; rest /= 10) {")
+	b.Indent()
+	b.P("length++;")
+	b.Unindent()
+	b.P("}")
+	b.P("bytes memory digits = new bytes(length);")
+	b.P("for (uint256 i = length; i > 0; i--) {")
+	b.Indent()
+	b.P("digits[i - 1] = bytes1(uint8(48 + value % 10));")
+	b.P("value /= 10;")
+	b.Unindent()
+	b.P("}")
+	b.P("return digits;")
+	b.Unindent()
+	b.P("}")
+	b.P0()
+
+	b.P("// Returns the decimal digits of value, preceded by a minus sign if it is negative")
+	b.P("function encode_decimal_int256(int256 value) internal pure returns (bytes memory) {")
+	b.Indent()
+	b.P("if (value >= 0) {")
+	b.Indent()
+	b.P("return encode_decimal_uint256(uint256(value));")
+	b.Unindent()
+	b.P("}")
+	b.P("return abi.encodePacked(\"-\", encode_decimal_uint256(~uint256(value) + 1));")
+	b.Unindent()
+	b.P("}")
+	b.P0()
+}
+
+// GenerateLeadingZeroCheck generates a check rejecting a non-zero decimal integer of buf at posVar written with leading zeros.
+// The value being non-zero guarantees a digit follows a minus sign.
+func (dg *DecimalGenerator) GenerateLeadingZeroCheck(solType string, posVar string, rejection string, b *WriteableBuffer) {
+	condition := fmt.Sprintf("buf[%s] == \"0\"", posVar)
+	if solType == "int256" {
+		condition = fmt.Sprintf("buf[%s] == \"0\" || (buf[%s] == \"-\" && buf[%s + 1] == \"0\")", posVar, posVar, posVar)
+	}
+	b.P(fmt.Sprintf("if (%s) {", condition))
+	b.Indent()
+	b.P(rejection)
+	b.Unindent()
+	b.P("}")
+}
@@ -0,0 +1,285 @@
+package generator
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+// Values of the Edition enum of descriptor.proto
+const (
+	editionProto2 int32 = 998
+	editionProto3 int32 = 999
+	edition2023   int32 = 1000
+	edition2024   int32 = 1001
+)
+
+// supportedEditions are the editions of the editions files the generator resolves features of, in ascending order
+var supportedEditions = []int32{edition2023, edition2024}
+
+// editionRange returns the range of editions the generator supports, declared to protoc.
+// It starts at the oldest supported syntax, proto2 and proto3 being editions of their own, and ends at the newest edition.
+func editionRange() (int32, int32) {
+	minimum, maximum := supportedEditions[0], supportedEditions[len(supportedEditions)-1]
+	if isSupportedSyntax("proto3") {
+		minimum = editionProto3
+	}
+	if isSupportedSyntax("proto2") {
+		minimum = editionProto2
+	}
+	return minimum, maximum
+}
+
+// Field numbers of edition related members that the descriptors of this protobuf version do not have,
+// so they are found among unknown fields like custom options
+const (
+	fileEditionNumber             protowire.Number = 14 // FileDescriptorProto.edition
+	fileOptionsFeaturesNumber     protowire.Number = 50 // FileOptions.features
+	messageOptionsFeaturesNumber  protowire.Number = 12 // MessageOptions.features
+	enumOptionsFeaturesNumber     protowire.Number = 7  // EnumOptions.features
+	fieldOptionsFeaturesNumber    protowire.Number = 21 // FieldOptions.features
+	oneofOptionsFeaturesNumber    protowire.Number = 1  // OneofOptions.features
+	responseMinimumEditionNumber  protowire.Number = 3  // CodeGeneratorResponse.minimum_edition
+	responseMaximumEditionNumber  protowire.Number = 4  // CodeGeneratorResponse.maximum_edition
+	featureFieldPresenceNumber    protowire.Number = 1  // FeatureSet.field_presence
+	featureEnumTypeNumber         protowire.Number = 2  // FeatureSet.enum_type
+	featureRepeatedEncodingNumber protowire.Number = 3  // FeatureSet.repeated_field_encoding
+	featureMessageEncodingNumber  protowire.Number = 5  // FeatureSet.message_encoding
+)
+
+// Values of the features the generator acts on, 0 being unknown
+const (
+	fieldPresenceExplicit       uint64 = 1
+	fieldPresenceImplicit       uint64 = 2
+	fieldPresenceLegacyRequired uint64 = 3
+
+	enumTypeOpen   uint64 = 1
+	enumTypeClosed uint64 = 2
+
+	repeatedFieldEncodingPacked   uint64 = 1
+	repeatedFieldEncodingExpanded uint64 = 2
+
+	messageEncodingLengthPrefixed uint64 = 1
+	messageEncodingDelimited      uint64 = 2
+)
+
+// featureSet holds the features the generator acts on, with 0 for features that are not set.
+// Enums are always decoded as closed, values out of their range failing decoding, so editions files must declare them so.
+type featureSet struct {
+	fieldPresence         uint64
+	enumType              uint64
+	repeatedFieldEncoding uint64
+	messageEncoding       uint64
+}
+
+// merge returns the features with those set in overrides replacing them, as features of inner elements do
+func (fs featureSet) merge(overrides featureSet) featureSet {
+	if overrides.fieldPresence != 0 {
+		fs.fieldPresence = overrides.fieldPresence
+	}
+	if overrides.enumType != 0 {
+		fs.enumType = overrides.enumType
+	}
+	if overrides.repeatedFieldEncoding != 0 {
+		fs.repeatedFieldEncoding = overrides.repeatedFieldEncoding
+	}
+	if overrides.messageEncoding != 0 {
+		fs.messageEncoding = overrides.messageEncoding
+	}
+	return fs
+}
+
+// fileEdition returns the edition of a file with syntax "editions", and whether it has one
+func fileEdition(protoFile *descriptorpb.FileDescriptorProto) (int32, bool) {
+	wireType, value, found := unknownFieldValue(protoFile.ProtoReflect().GetUnknown(), fileEditionNumber)
+	if !found || wireType != protowire.VarintType {
+		return 0, false
+	}
+	edition, n := protowire.ConsumeVarint(value)
+	if n < 0 {
+		return 0, false
+	}
+	return int32(edition), true
+}
+
+// checkEdition validates that a file with syntax "editions" is of an edition the generator supports
+func checkEdition(protoFile *descriptorpb.FileDescriptorProto) error {
+	edition, ok := fileEdition(protoFile)
+	if !ok {
+		return fmt.Errorf("file %s has syntax editions but no edition", protoFile.GetName())
+	}
+	for _, supported := range supportedEditions {
+		if edition == supported {
+			return nil
+		}
+	}
+	return fmt.Errorf("file %s has unsupported edition %d, only editions 2023 and 2024 are supported", protoFile.GetName(), edition)
+}
+
+// fileDefaultFeatures returns the features of a file before any options set them, as defined by its syntax or edition.
+// Editions 2023 and 2024 only differ in features the generator does not act on.
+func fileDefaultFeatures(protoFile *descriptorpb.FileDescriptorProto) featureSet {
+	switch fileSyntax(protoFile) {
+	case "proto2":
+		return featureSet{fieldPresenceExplicit, enumTypeClosed, repeatedFieldEncodingExpanded, messageEncodingLengthPrefixed}
+	case "proto3":
+		return featureSet{fieldPresenceImplicit, enumTypeOpen, repeatedFieldEncodingPacked, messageEncodingLengthPrefixed}
+	}
+	return featureSet{fieldPresenceExplicit, enumTypeOpen, repeatedFieldEncodingPacked, messageEncodingLengthPrefixed}
+}
+
+// optionFeatures returns the features set in the options of an element, held in the features member of the given number.
+// protoc writes the features of an element once, so its last occurrence is the whole feature set.
+func optionFeatures(options proto.Message, number protowire.Number) featureSet {
+	var features featureSet
+	if options == nil || !options.ProtoReflect().IsValid() {
+		return features
+	}
+	wireType, value, found := unknownFieldValue(options.ProtoReflect().GetUnknown(), number)
+	if !found || wireType != protowire.BytesType {
+		return features
+	}
+	content, n := protowire.ConsumeBytes(value)
+	if n < 0 {
+		return features
+	}
+
+	read := func(number protowire.Number) uint64 {
+		wireType, value, found := unknownFieldValue(content, number)
+		if !found || wireType != protowire.VarintType {
+			return 0
+		}
+		v, n := protowire.ConsumeVarint(value)
+		if n < 0 {
+			return 0
+		}
+		return v
+	}
+	features.fieldPresence = read(featureFieldPresenceNumber)
+	features.enumType = read(featureEnumTypeNumber)
+	features.repeatedFieldEncoding = read(featureRepeatedEncodingNumber)
+	features.messageEncoding = read(featureMessageEncodingNumber)
+	return features
+}
+
+// resolveFeatures resolves the features of every field of the given files, from the defaults of their file
+// through the options of the file, enclosing messages, oneof and the field itself.
+// Packing is only taken from features in editions files, proto3 files keep requiring [packed = true] and repeated
+// numeric fields of proto2 files without it are expanded, as proto2 defines.
+func (g *Generator) resolveFeatures(protoFiles []*descriptorpb.FileDescriptorProto) {
+	g.presenceFields = make(map[*descriptorpb.FieldDescriptorProto]bool)
+	g.requiredFields = make(map[*descriptorpb.FieldDescriptorProto]bool)
+	g.packedFields = make(map[*descriptorpb.FieldDescriptorProto]bool)
+	g.expandedFields = make(map[*descriptorpb.FieldDescriptorProto]bool)
+	g.delimitedFields = make(map[*descriptorpb.FieldDescriptorProto]bool)
+
+	var resolveMessage func(msg *descriptorpb.DescriptorProto, parent featureSet, editions bool)
+	resolveMessage = func(msg *descriptorpb.DescriptorProto, parent featureSet, editions bool) {
+		messageFeatures := parent.merge(optionFeatures(msg.GetOptions(), messageOptionsFeaturesNumber))
+
+		for _, field := range msg.GetField() {
+			features := messageFeatures
+			if field.OneofIndex != nil && int(field.GetOneofIndex()) < len(msg.GetOneofDecl()) {
+				features = features.merge(optionFeatures(msg.GetOneofDecl()[field.GetOneofIndex()].GetOptions(), oneofOptionsFeaturesNumber))
+			}
+			features = features.merge(optionFeatures(field.GetOptions(), fieldOptionsFeaturesNumber))
+
+			if editions && field.GetType() == descriptorpb.FieldDescriptorProto_TYPE_MESSAGE && features.messageEncoding == messageEncodingDelimited {
+				g.delimitedFields[field] = true
+			}
+			if isFieldRepeated(field) {
+				if editions && features.repeatedFieldEncoding == repeatedFieldEncodingPacked && isPackableType(field.GetType()) {
+					g.packedFields[field] = true
+				}
+				if !editions && features.repeatedFieldEncoding == repeatedFieldEncodingExpanded && isPackableType(field.GetType()) && !field.GetOptions().GetPacked() {
+					g.expandedFields[field] = true
+				}
+				continue
+			}
+			switch {
+			case field.GetProto3Optional():
+				// proto3 optional fields have explicit presence whatever the file defaults
+				g.presenceFields[field] = true
+			case field.GetLabel() == descriptorpb.FieldDescriptorProto_LABEL_REQUIRED,
+				features.fieldPresence == fieldPresenceLegacyRequired:
+				g.presenceFields[field] = true
+				g.requiredFields[field] = true
+			case features.fieldPresence == fieldPresenceExplicit:
+				g.presenceFields[field] = true
+			}
+		}
+
+		for _, nested := range msg.GetNestedType() {
+			resolveMessage(nested, messageFeatures, editions)
+		}
+	}
+
+	for _, protoFile := range protoFiles {
+		features := fileDefaultFeatures(protoFile).merge(optionFeatures(protoFile.GetOptions(), fileOptionsFeaturesNumber))
+		editions := fileSyntax(protoFile) == "editions"
+		for _, msg := range protoFile.GetMessageType() {
+			resolveMessage(msg, features, editions)
+		}
+	}
+}
+
+// checkEnumTypes validates that the enums of an editions file are closed. Solidity enums cannot hold the values missing
+// from their definition that open enums accept, so decoders reject these values, which only closed enums allow.
+func checkEnumTypes(protoFile *descriptorpb.FileDescriptorProto) error {
+	fileFeatures := fileDefaultFeatures(protoFile).merge(optionFeatures(protoFile.GetOptions(), fileOptionsFeaturesNumber))
+
+	checkEnums := func(enums []*descriptorpb.EnumDescriptorProto, parent featureSet) error {
+		for _, enum := range enums {
+			features := parent.merge(optionFeatures(enum.GetOptions(), enumOptionsFeaturesNumber))
+			if features.enumType != enumTypeClosed {
+				return fmt.Errorf("enum '%s' is OPEN, which is not supported since Solidity enums cannot hold undefined values, use features.enum_type = CLOSED instead", enum.GetName())
+			}
+		}
+		return nil
+	}
+
+	var checkMessages func(messages []*descriptorpb.DescriptorProto, parent featureSet) error
+	checkMessages = func(messages []*descriptorpb.DescriptorProto, parent featureSet) error {
+		for _, msg := range messages {
+			features := parent.merge(optionFeatures(msg.GetOptions(), messageOptionsFeaturesNumber))
+			if err := checkEnums(msg.GetEnumType(), features); err != nil {
+				return err
+			}
+			if err := checkMessages(msg.GetNestedType(), features); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := checkEnums(protoFile.GetEnumType(), fileFeatures); err != nil {
+		return err
+	}
+	return checkMessages(protoFile.GetMessageType(), fileFeatures)
+}
+
+// isPackableType checks if repeated fields of a type can use packed encoding, which scalar numeric types and enums can
+func isPackableType(fieldType descriptorpb.FieldDescriptorProto_Type) bool {
+	switch fieldType {
+	case descriptorpb.FieldDescriptorProto_TYPE_STRING,
+		descriptorpb.FieldDescriptorProto_TYPE_BYTES,
+		descriptorpb.FieldDescriptorProto_TYPE_MESSAGE,
+		descriptorpb.FieldDescriptorProto_TYPE_GROUP:
+		return false
+	}
+	return true
+}
+
+// setSupportedEditions declares the editions the generator supports in a response
+func setSupportedEditions(response *pluginpb.CodeGeneratorResponse) {
+	unknown := response.ProtoReflect().GetUnknown()
+	unknown = protowire.AppendTag(unknown, responseMinimumEditionNumber, protowire.VarintType)
+	minimum, maximum := editionRange()
+	unknown = protowire.AppendVarint(unknown, uint64(minimum))
+	unknown = protowire.AppendTag(unknown, responseMaximumEditionNumber, protowire.VarintType)
+	unknown = protowire.AppendVarint(unknown, uint64(maximum))
+	response.ProtoReflect().SetUnknown(unknown)
+}
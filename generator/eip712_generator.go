@@ -0,0 +1,296 @@
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+// EIP712Generator handles generation of EIP-712 typed data hashing for messages
+type EIP712Generator struct {
+	g *Generator
+}
+
+// NewEIP712Generator creates a new EIP-712 generator
+func NewEIP712Generator(g *Generator) *EIP712Generator {
+	return &EIP712Generator{
+		g: g,
+	}
+}
+
+// eip712Member is a member of an EIP-712 struct type, in the format used by signing libraries
+type eip712Member struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// eip712Reference is a struct type referenced by a member
+type eip712Reference struct {
+	name       string
+	descriptor *descriptorpb.DescriptorProto
+}
+
+// memberType returns the EIP-712 type of a field, and the struct type it references if any.
+// Types follow the struct fields: enums are uint8, recursive fields are bytes and maps are arrays of entry structs.
+func (eg *EIP712Generator) memberType(field *descriptorpb.FieldDescriptorProto, fieldName string, parent *descriptorpb.DescriptorProto) (string, *eip712Reference, error) {
+	arrayStr := ""
+	if isFieldRepeated(field) {
+		arrayStr = "[]"
+	}
+
+	switch {
+	case eg.g.isMapField(field, parent):
+		keyType, valueType, err := eg.g.getMapKeyValueTypes(field, parent)
+		if err != nil {
+			return "", nil, err
+		}
+		wrapperName := CreateMapEntryWrapperName(fieldName)
+		return wrapperName + "[]", &eip712Reference{
+			name:       wrapperName,
+			descriptor: eg.g.createMapWrapperMessage(fieldName, keyType, valueType),
+		}, nil
+	case field.GetType() == descriptorpb.FieldDescriptorProto_TYPE_MESSAGE && !isEmbeddedMessageField(field):
+		return "", nil, fmt.Errorf("eip712 does not support field %s of well-known type %s", fieldName, field.GetTypeName())
+	case isEmbeddedMessageField(field) && eg.g.isRecursiveField(field):
+		return "bytes", nil, nil
+	case isEmbeddedMessageField(field):
+		fullName := strings.TrimPrefix(field.GetTypeName(), ".")
+		descriptor, ok := eg.g.messageRegistry[fullName]
+		if !ok {
+			return "", nil, fmt.Errorf("eip712 cannot resolve message type %s of field %s", fullName, fieldName)
+		}
+		name := eg.g.messageStructNames[fullName]
+		return name + arrayStr, &eip712Reference{
+			name:       name,
+			descriptor: descriptor,
+		}, nil
+	case field.GetType() == descriptorpb.FieldDescriptorProto_TYPE_ENUM:
+		return "uint8" + arrayStr, nil, nil
+	default:
+		solType, err := fieldToSol(field)
+		if err != nil {
+			return "", nil, err
+		}
+		return solType + arrayStr, nil, nil
+	}
+}
+
+// collectTypes adds the EIP-712 type of a message and of every struct type it references to types
+func (eg *EIP712Generator) collectTypes(name string, descriptor *descriptorpb.DescriptorProto, types map[string][]eip712Member) error {
+	if _, exists := types[name]; exists {
+		return nil
+	}
+
+	fieldNameMap, err := NewFieldProcessor().ProcessFieldNames(descriptor.GetField())
+	if err != nil {
+		return err
+	}
+
+	members := make([]eip712Member, 0, len(descriptor.GetField()))
+	var references []*eip712Reference
+	for _, field := range descriptor.GetField() {
+		fieldName := fieldNameMap[field.GetNumber()]
+		memberType, reference, err := eg.memberType(field, fieldName, descriptor)
+		if err != nil {
+			return err
+		}
+		members = append(members, eip712Member{Name: fieldName, Type: memberType})
+		if reference != nil {
+			references = append(references, reference)
+		}
+	}
+	types[name] = members
+
+	for _, reference := range references {
+		if err := eg.collectTypes(reference.name, reference.descriptor, types); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encodeType returns the EIP-712 encoding of a type: the primary type followed by the referenced types sorted by name
+func encodeType(primaryName string, types map[string][]eip712Member) string {
+	var referencedNames []string
+	for name := range types {
+		if name != primaryName {
+			referencedNames = append(referencedNames, name)
+		}
+	}
+	sort.Strings(referencedNames)
+
+	var sb strings.Builder
+	for _, name := range append([]string{primaryName}, referencedNames...) {
+		members := make([]string, len(types[name]))
+		for i, member := range types[name] {
+			members[i] = member.Type + " " + member.Name
+		}
+		sb.WriteString(fmt.Sprintf("%s(%s)", name, strings.Join(members, ",")))
+	}
+	return sb.String()
+}
+
+// GenerateHashStruct generates the TYPEHASH constant and hash_struct function of a codec library
+func (eg *EIP712Generator) GenerateHashStruct(structName string, descriptor *descriptorpb.DescriptorProto, fieldNameMap map[int32]string, b *WriteableBuffer) error {
+	primaryName := structName[strings.LastIndex(structName, ".")+1:]
+	types := make(map[string][]eip712Member)
+	if err := eg.collectTypes(primaryName, descriptor, types); err != nil {
+		return err
+	}
+
+	b.P(fmt.Sprintf("bytes32 internal constant TYPEHASH = keccak256(\"%s\");", encodeType(primaryName, types)))
+	b.P0()
+
+	fields := descriptor.GetField()
+	b.P(fmt.Sprintf("function hash_struct(%s memory instance) internal pure returns (bytes32) {", structName))
+	b.Indent()
+	b.P(fmt.Sprintf("bytes32[] memory words = new bytes32[](%d);", len(fields)))
+	for i, field := range fields {
+		fieldName := fieldNameMap[field.GetNumber()]
+		word := fmt.Sprintf("words[%d]", i)
+		value := "instance." + fieldName
+
+		if !isFieldRepeated(field) {
+			expression, err := eg.encodeValue(field, fieldName, descriptor, value)
+			if err != nil {
+				return err
+			}
+			b.P(fmt.Sprintf("%s = %s;", word, expression))
+			continue
+		}
+
+		switch field.GetType() {
+		case descriptorpb.FieldDescriptorProto_TYPE_STRING,
+			descriptorpb.FieldDescriptorProto_TYPE_BYTES,
+			descriptorpb.FieldDescriptorProto_TYPE_MESSAGE:
+			// Arrays of reference types hash the concatenated hashes of their elements
+			expression, err := eg.encodeValue(field, fieldName, descriptor, value+"[i]")
+			if err != nil {
+				return err
+			}
+			b.P("{")
+			b.Indent()
+			b.P(fmt.Sprintf("bytes32[] memory hashes = new bytes32[](%s.length);", value))
+			b.P("for (uint256 i = 0; i < hashes.length; i++) {")
+			b.Indent()
+			b.P(fmt.Sprintf("hashes[i] = %s;", expression))
+			b.Unindent()
+			b.P("}")
+			b.P(fmt.Sprintf("%s = keccak256(abi.encodePacked(hashes));", word))
+			b.Unindent()
+			b.P("}")
+		default:
+			// Packed encoding pads array elements to 32 bytes, matching their EIP-712 encoding
+			b.P(fmt.Sprintf("%s = keccak256(abi.encodePacked(%s));", word, value))
+		}
+	}
+	b.P("return keccak256(abi.encodePacked(TYPEHASH, words));")
+	b.Unindent()
+	b.P("}")
+	b.P0()
+
+	return nil
+}
+
+// encodeValue returns the expression encoding a single value of a field to its 32 byte EIP-712 word
+func (eg *EIP712Generator) encodeValue(field *descriptorpb.FieldDescriptorProto, fieldName string, parent *descriptorpb.DescriptorProto, value string) (string, error) {
+	switch field.GetType() {
+	case descriptorpb.FieldDescriptorProto_TYPE_MESSAGE:
+		if eg.g.isMapField(field, parent) {
+			return fmt.Sprintf("%sCodec.hash_struct(%s)", CreateMapEntryWrapperName(fieldName), value), nil
+		}
+		if !isEmbeddedMessageField(field) {
+			return "", fmt.Errorf("eip712 does not support field %s of well-known type %s", fieldName, field.GetTypeName())
+		}
+		if eg.g.isRecursiveField(field) {
+			return fmt.Sprintf("keccak256(%s)", value), nil
+		}
+		typeName, err := eg.g.getSolTypeName(field)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s.hash_struct(%s)", CodecLibraryName(typeName), value), nil
+	case descriptorpb.FieldDescriptorProto_TYPE_STRING,
+		descriptorpb.FieldDescriptorProto_TYPE_BYTES:
+		solType, fixedSize, err := fieldTypeOverride(field)
+		if err != nil {
+			return "", err
+		}
+		switch {
+		case solType == "address":
+			return fmt.Sprintf("bytes32(uint256(uint160(%s)))", value), nil
+		case solType == "uint256":
+			return fmt.Sprintf("bytes32(%s)", value), nil
+		case solType == "int256":
+			return fmt.Sprintf("bytes32(uint256(%s))", value), nil
+		case fixedSize > 0:
+			// Fixed size byte arrays are right-padded
+			return fmt.Sprintf("bytes32(%s)", value), nil
+		case field.GetType() == descriptorpb.FieldDescriptorProto_TYPE_STRING && solType != "bytes":
+			return fmt.Sprintf("keccak256(bytes(%s))", value), nil
+		}
+		return fmt.Sprintf("keccak256(%s)", value), nil
+	case descriptorpb.FieldDescriptorProto_TYPE_BOOL:
+		return fmt.Sprintf("%s ? bytes32(uint256(1)) : bytes32(0)", value), nil
+	case descriptorpb.FieldDescriptorProto_TYPE_ENUM:
+		return fmt.Sprintf("bytes32(uint256(%s))", value), nil
+	}
+
+	solType, err := typeToSol(field.GetType())
+	if err != nil {
+		return "", err
+	}
+	if strings.HasPrefix(solType, "int") {
+		// Signed integers are sign-extended to 256 bits
+		return fmt.Sprintf("bytes32(uint256(int256(%s)))", value), nil
+	}
+	return fmt.Sprintf("bytes32(uint256(%s))", value), nil
+}
+
+// GenerateTypesFile generates a JSON file with the EIP-712 types of every message of a file, keyed by struct name,
+// for signers to pass to their typed data library together with the primary type and domain
+func (eg *EIP712Generator) GenerateTypesFile(protoFile *descriptorpb.FileDescriptorProto, solFileName string) (*pluginpb.CodeGeneratorResponse_File, error) {
+	types := make(map[string][]eip712Member)
+	var collect func(scope string, msg *descriptorpb.DescriptorProto) error
+	collect = func(scope string, msg *descriptorpb.DescriptorProto) error {
+		fullName := scope + msg.GetName()
+		if msg.GetOptions().GetMapEntry() {
+			return nil
+		}
+		if err := eg.collectTypes(eg.g.messageStructNames[fullName], msg, types); err != nil {
+			return err
+		}
+		for _, nested := range msg.GetNestedType() {
+			if err := collect(fullName+".", nested); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	scope := ""
+	if len(protoFile.GetPackage()) > 0 {
+		scope = protoFile.GetPackage() + "."
+	}
+	for _, msg := range protoFile.GetMessageType() {
+		if err := collect(scope, msg); err != nil {
+			return nil, err
+		}
+	}
+
+	// Keys of maps are marshaled in sorted order, so the output is deterministic
+	content, err := json.MarshalIndent(types, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return &pluginpb.CodeGeneratorResponse_File{
+		Name:    proto.String(strings.TrimSuffix(solFileName, ".sol") + ".eip712.json"),
+		Content: proto.String(string(content) + "\n"),
+	}, nil
+}
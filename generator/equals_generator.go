@@ -0,0 +1,126 @@
+package generator
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// EqualsGenerator handles generation of deep equality functions for messages
+type EqualsGenerator struct {
+	g *Generator
+}
+
+// NewEqualsGenerator creates a new equals generator
+func NewEqualsGenerator(g *Generator) *EqualsGenerator {
+	return &EqualsGenerator{
+		g: g,
+	}
+}
+
+// GenerateEquals generates equals, which compares two messages field by field.
+// Oneof members are regular struct fields, so they are compared like any other field.
+func (eg *EqualsGenerator) GenerateEquals(structName string, descriptor *descriptorpb.DescriptorProto, fieldNameMap map[int32]string, b *WriteableBuffer) error {
+	b.P(fmt.Sprintf("function equals(%s memory a, %s memory b) internal pure returns (bool) {", structName, structName))
+	b.Indent()
+	for _, field := range descriptor.GetField() {
+		fieldName := fieldNameMap[field.GetNumber()]
+
+		if isFieldRepeated(field) {
+			err := eg.generateArrayComparison(field, fieldName, descriptor, b)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		condition, err := eg.valueInequality(field, fieldName, descriptor, "a."+fieldName, "b."+fieldName)
+		if err != nil {
+			return err
+		}
+		eg.generateReturnFalseIf(condition, b)
+		if eg.g.hasFieldPresence(field) {
+			// A field set to its default value differs from an unset one
+			member := presenceMemberName(fieldName)
+			eg.generateReturnFalseIf(fmt.Sprintf("a.%s != b.%s", member, member), b)
+		}
+	}
+	if eg.g.unknownFieldsFlag == unknownFieldsFlagPreserve {
+		eg.generateReturnFalseIf("keccak256(a._unknown) != keccak256(b._unknown)", b)
+	}
+	b.P("return true;")
+	b.Unindent()
+	b.P("}")
+	b.P0()
+
+	return nil
+}
+
+// generateArrayComparison generates the comparison of a repeated field
+func (eg *EqualsGenerator) generateArrayComparison(field *descriptorpb.FieldDescriptorProto, fieldName string, descriptor *descriptorpb.DescriptorProto, b *WriteableBuffer) error {
+	switch field.GetType() {
+	case descriptorpb.FieldDescriptorProto_TYPE_STRING,
+		descriptorpb.FieldDescriptorProto_TYPE_BYTES,
+		descriptorpb.FieldDescriptorProto_TYPE_MESSAGE:
+		// Arrays of reference types are compared element by element
+		condition, err := eg.valueInequality(field, fieldName, descriptor, fmt.Sprintf("a.%s[i]", fieldName), fmt.Sprintf("b.%s[i]", fieldName))
+		if err != nil {
+			return err
+		}
+		eg.generateReturnFalseIf(fmt.Sprintf("a.%s.length != b.%s.length", fieldName, fieldName), b)
+		b.P(fmt.Sprintf("for (uint256 i = 0; i < a.%s.length; i++) {", fieldName))
+		b.Indent()
+		eg.generateReturnFalseIf(condition, b)
+		b.Unindent()
+		b.P("}")
+	default:
+		// Packed encoding pads array elements to 32 bytes, so arrays of different lengths never collide
+		eg.generateReturnFalseIf(fmt.Sprintf("keccak256(abi.encodePacked(a.%s)) != keccak256(abi.encodePacked(b.%s))", fieldName, fieldName), b)
+	}
+	return nil
+}
+
+// valueInequality returns the condition under which two single values of a field differ
+func (eg *EqualsGenerator) valueInequality(field *descriptorpb.FieldDescriptorProto, fieldName string, descriptor *descriptorpb.DescriptorProto, a string, b string) (string, error) {
+	switch field.GetType() {
+	case descriptorpb.FieldDescriptorProto_TYPE_MESSAGE:
+		switch {
+		case eg.g.isMapField(field, descriptor):
+			return fmt.Sprintf("!%sCodec.equals(%s, %s)", CreateMapEntryWrapperName(fieldName), a, b), nil
+		case !isEmbeddedMessageField(field):
+			// Well-known types have no codec, so they are compared by their ABI encoding
+			return fmt.Sprintf("keccak256(abi.encode(%s)) != keccak256(abi.encode(%s))", a, b), nil
+		case eg.g.isRecursiveField(field):
+			return fmt.Sprintf("keccak256(%s) != keccak256(%s)", a, b), nil
+		}
+		typeName, err := eg.g.getSolTypeName(field)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("!%s.equals(%s, %s)", CodecLibraryName(typeName), a, b), nil
+	case descriptorpb.FieldDescriptorProto_TYPE_STRING,
+		descriptorpb.FieldDescriptorProto_TYPE_BYTES:
+		solType, fixedSize, err := fieldTypeOverride(field)
+		if err != nil {
+			return "", err
+		}
+		switch {
+		case fixedSize > 0 || isLargeIntegerType(solType):
+			return fmt.Sprintf("%s != %s", a, b), nil
+		case field.GetType() == descriptorpb.FieldDescriptorProto_TYPE_STRING && solType != "bytes":
+			return fmt.Sprintf("keccak256(bytes(%s)) != keccak256(bytes(%s))", a, b), nil
+		}
+		return fmt.Sprintf("keccak256(%s) != keccak256(%s)", a, b), nil
+	default:
+		return fmt.Sprintf("%s != %s", a, b), nil
+	}
+}
+
+// generateReturnFalseIf generates an early return of false under the given condition
+func (eg *EqualsGenerator) generateReturnFalseIf(condition string, b *WriteableBuffer) {
+	b.P(fmt.Sprintf("if (%s) {", condition))
+	b.Indent()
+	b.P("return false;")
+	b.Unindent()
+	b.P("}")
+}
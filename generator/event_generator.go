@@ -0,0 +1,81 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// maxIndexedEventParameters is the number of indexed parameters a non-anonymous event can have
+const maxIndexedEventParameters = 3
+
+// EventGenerator handles generation of events publishing decoded messages
+type EventGenerator struct {
+	g *Generator
+}
+
+// NewEventGenerator creates a new event generator
+func NewEventGenerator(g *Generator) *EventGenerator {
+	return &EventGenerator{
+		g: g,
+	}
+}
+
+// GenerateEvent generates the <Msg>Decoded event with the scalar fields of a message, and the emit_<msg> helper emitting it.
+// Fields marked with (sol.indexed) become indexed parameters. Messages without scalar fields get no event.
+func (eg *EventGenerator) GenerateEvent(structName string, descriptor *descriptorpb.DescriptorProto, fieldNameMap map[int32]string, b *WriteableBuffer) error {
+	messageName := structName[strings.LastIndex(structName, ".")+1:]
+
+	var parameters []string
+	var arguments []string
+	indexedCount := 0
+	for _, field := range descriptor.GetField() {
+		fieldName := fieldNameMap[field.GetNumber()]
+
+		if isFieldRepeated(field) || field.GetType() == descriptorpb.FieldDescriptorProto_TYPE_MESSAGE {
+			if isIndexedField(field) {
+				return fmt.Errorf("field %s.%s cannot be indexed, only scalar fields are event parameters", messageName, fieldName)
+			}
+			continue
+		}
+
+		var fieldType string
+		var err error
+		if field.GetType() == descriptorpb.FieldDescriptorProto_TYPE_ENUM {
+			fieldType, err = eg.g.getSolTypeName(field)
+		} else {
+			fieldType, err = fieldToSol(field)
+		}
+		if err != nil {
+			return err
+		}
+
+		if isIndexedField(field) {
+			indexedCount++
+			fieldType += " indexed"
+		}
+		parameters = append(parameters, fmt.Sprintf("%s %s", fieldType, fieldName))
+		arguments = append(arguments, "instance."+fieldName)
+	}
+
+	if indexedCount > maxIndexedEventParameters {
+		return fmt.Errorf("message %s has %d indexed fields, events allow at most %d", messageName, indexedCount, maxIndexedEventParameters)
+	}
+	if len(parameters) == 0 {
+		return nil
+	}
+
+	eventName := messageName + "Decoded"
+	b.P(fmt.Sprintf("event %s(%s);", eventName, strings.Join(parameters, ", ")))
+	b.P0()
+
+	b.P(fmt.Sprintf("function emit_%s(%s memory instance) internal {", toSnakeCase(messageName), structName))
+	b.Indent()
+	b.P(fmt.Sprintf("emit %s(%s);", eventName, strings.Join(arguments, ", ")))
+	b.Unindent()
+	b.P("}")
+	b.P0()
+
+	return nil
+}
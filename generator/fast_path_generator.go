@@ -0,0 +1,151 @@
+package generator
+
+import (
+	"fmt"
+	"sort"
+
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// FastPathGenerator handles generation of the inline assembly fast paths of optimize=gas decoders.
+// Every fast path only handles inputs that ProtobufLib accepts, and falls back to ProtobufLib
+// otherwise, so decoding results are identical to the reference mode.
+type FastPathGenerator struct {
+	g *Generator
+}
+
+// NewFastPathGenerator creates a new fast path generator
+func NewFastPathGenerator(g *Generator) *FastPathGenerator {
+	return &FastPathGenerator{
+		g: g,
+	}
+}
+
+// GenerateFastPathHelpers generates the assembly helpers shared by all codecs of the main library
+func (fpg *FastPathGenerator) GenerateFastPathHelpers(b *WriteableBuffer) {
+	b.P("// Reads the varint at pos if it is a single byte, the common case for keys and small values")
+	b.P("function read_single_byte_varint(uint64 pos, bytes memory buf) internal pure returns (bool, uint64) {")
+	b.Indent()
+	b.P("if (pos >= buf.length) {")
+	b.Indent()
+	b.P("return (false, 0);")
+	b.Unindent()
+	b.P("}")
+	b.P("uint256 value;")
+	b.P("assembly {")
+	b.Indent()
+	b.P("value := byte(0, mload(add(add(buf, 32), pos)))")
+	b.Unindent()
+	b.P("}")
+	b.P("return (value < 0x80, uint64(value));")
+	b.Unindent()
+	b.P("}")
+	b.P0()
+}
+
+// GenerateKeyDecoding generates key decoding for the decoder loop.
+// Single byte keys with a field number and a supported wire type are decoded inline.
+func (fpg *FastPathGenerator) GenerateKeyDecoding(structName string, b *WriteableBuffer) {
+	libraryName := libraryPrefix(structName)
+
+	b.P("bool success;")
+	b.P("uint64 field_number;")
+	b.P("ProtobufLib.WireType wire_type;")
+	b.P("uint64 key;")
+	b.P(fmt.Sprintf("(success, key) = %sread_single_byte_varint(pos, buf);", libraryName))
+	b.P("uint64 wire_type_value = key & 7;")
+	b.P("if (success && key >= 8 && (wire_type_value == 0 || wire_type_value == 1 || wire_type_value == 2 || wire_type_value == 5)) {")
+	b.Indent()
+	b.P("field_number = key >> 3;")
+	b.P("wire_type = ProtobufLib.WireType(wire_type_value);")
+	b.P("pos += 1;")
+	b.Unindent()
+	b.P("} else {")
+	b.Indent()
+	if fpg.g.strictCanonical {
+		b.P(fmt.Sprintf("if (!%sis_minimal_varint(pos, buf)) {", libraryName))
+		b.Indent()
+		b.P("return (false, pos, instance);")
+		b.Unindent()
+		b.P("}")
+	}
+	b.P("(success, pos, field_number, wire_type) = ProtobufLib.decode_key(pos, buf);")
+	b.P("if (!success) {")
+	b.Indent()
+	b.P("return (false, pos, instance);")
+	b.Unindent()
+	b.P("}")
+	b.Unindent()
+	b.P("}")
+}
+
+// GenerateVarintDecoding generates decoding of a varint into value, reading single byte varints inline.
+// fastValue converts the single byte varint to the value type, the ProtobufLib decodeFunction handles the rest.
+func (fpg *FastPathGenerator) GenerateVarintDecoding(decodeFunction string, fastCondition string, fastValue string, structName string, chg *CodecHelperGenerator, b *WriteableBuffer) {
+	b.P("uint64 single_byte;")
+	b.P(fmt.Sprintf("(success, single_byte) = %sread_single_byte_varint(pos, buf);", libraryPrefix(structName)))
+	b.P(fmt.Sprintf("if (%s) {", fastCondition))
+	b.Indent()
+	b.P(fmt.Sprintf("value = %s;", fastValue))
+	b.P("new_pos = pos + 1;")
+	b.Unindent()
+	b.P("} else {")
+	b.Indent()
+	chg.generateMinimalVarintCheck("pos", structName, b)
+	b.P(fmt.Sprintf("(success, new_pos, value) = %s(pos, buf);", decodeFunction))
+	b.P("if (!success) {")
+	b.Indent()
+	b.P("return (false, pos);")
+	b.Unindent()
+	b.P("}")
+	b.Unindent()
+	b.P("}")
+}
+
+// dispatchChainLength is the number of field numbers compared one after the other, below which binary search
+// no longer saves comparisons
+const dispatchChainLength = 4
+
+// GenerateDispatch generates a decode_field that finds the decoder of a field with a binary search over the
+// sorted field numbers, so decoding a field takes a logarithmic number of comparisons and no memory allocation
+func (fpg *FastPathGenerator) GenerateDispatch(structName string, fields []*descriptorpb.FieldDescriptorProto, b *WriteableBuffer) {
+	fieldNumbers := make([]int32, 0, len(fields))
+	for _, field := range fields {
+		fieldNumbers = append(fieldNumbers, field.GetNumber())
+	}
+	sort.Slice(fieldNumbers, func(i, j int) bool { return fieldNumbers[i] < fieldNumbers[j] })
+
+	b.P(fmt.Sprintf("function decode_field(uint64 pos, bytes memory buf, uint64 len, uint64 field_number, %s memory instance, uint64 depth) internal pure returns (bool, uint64) {", structName))
+	b.Indent()
+	generateDispatchRange(fieldNumbers, b)
+	b.P("return (false, pos); // Unknown field number")
+	b.Unindent()
+	b.P("}")
+	b.P0()
+}
+
+// generateDispatchRange generates the comparisons of decode_field for sorted field numbers.
+// Unmatched field numbers fall through to the end of decode_field.
+func generateDispatchRange(fieldNumbers []int32, b *WriteableBuffer) {
+	if len(fieldNumbers) <= dispatchChainLength {
+		for _, fieldNumber := range fieldNumbers {
+			b.P(fmt.Sprintf("if (field_number == %d) {", fieldNumber))
+			b.Indent()
+			b.P(fmt.Sprintf("return decode_%d(pos, buf, len, instance, depth);", fieldNumber))
+			b.Unindent()
+			b.P("}")
+		}
+		return
+	}
+
+	middle := len(fieldNumbers) / 2
+	b.P(fmt.Sprintf("if (field_number < %d) {", fieldNumbers[middle]))
+	b.Indent()
+	generateDispatchRange(fieldNumbers[:middle], b)
+	b.Unindent()
+	b.P("} else {")
+	b.Indent()
+	generateDispatchRange(fieldNumbers[middle:], b)
+	b.Unindent()
+	b.P("}")
+}
@@ -3,6 +3,7 @@ package generator
 import (
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
 
 	"google.golang.org/protobuf/types/descriptorpb"
@@ -13,6 +14,17 @@ func (g *Generator) generateMessageDecoder(structName string, fields []*descript
 	// Top-level decoder function
 	b.P(fmt.Sprintf("function decode(uint64 initial_pos, bytes memory buf, uint64 len) internal pure returns (bool, uint64, %s memory) {", structName))
 	b.Indent()
+	b.P("return decode(initial_pos, buf, len, 0);")
+	b.Unindent()
+	b.P("}")
+	b.P("")
+
+	// Decoder function for messages nested at the given depth
+	b.P(fmt.Sprintf("function decode(uint64 initial_pos, bytes memory buf, uint64 len, uint64 depth) internal pure returns (bool, uint64, %s memory) {", structName))
+	b.Indent()
+
+	g.generateDepthCheck(structName, b)
+	b.P("")
 
 	b.P("// Message instance")
 	b.P(fmt.Sprintf("%s memory instance;", structName))
@@ -22,39 +34,83 @@ func (g *Generator) generateMessageDecoder(structName string, fields []*descript
 	b.P("uint64 pos = initial_pos;")
 	b.P("")
 
+	presenceGen := NewPresenceGenerator(g)
+	err := presenceGen.GenerateDefaultValues(fields, fieldNameMap, b)
+	if err != nil {
+		return err
+	}
+
 	b.P("// Sanity checks")
+	if g.solidityVersion.supportsUnchecked() {
+		// The overflow check relies on wrapping arithmetic
+		b.P("unchecked {")
+		b.Indent()
+	}
 	b.P("if (pos + len < pos) {")
 	b.Indent()
 	b.P("return (false, pos, instance);")
 	b.Unindent()
 	b.P("}")
+	if g.solidityVersion.supportsUnchecked() {
+		b.Unindent()
+		b.P("}")
+	}
 	b.P("")
 
 	b.P("while (pos - initial_pos < len) {")
 	b.Indent()
+	if g.unknownFieldsFlag == unknownFieldsFlagPreserve {
+		b.P("// Start of the field, kept for preserving unknown fields")
+		b.P("uint64 key_pos = pos;")
+		b.P("")
+	}
 	b.P("// Decode the key (field number and wire type)")
-	b.P("bool success;")
-	b.P("uint64 field_number;")
-	b.P("ProtobufLib.WireType wire_type;")
-	b.P("(success, pos, field_number, wire_type) = ProtobufLib.decode_key(pos, buf);")
-	b.P("if (!success) {")
-	b.Indent()
-	b.P("return (false, pos, instance);")
-	b.Unindent()
-	b.P("}")
+	if g.optimizeFlag == optimizeFlagGas {
+		NewFastPathGenerator(g).GenerateKeyDecoding(structName, b)
+	} else {
+		if g.strictCanonical {
+			b.P(fmt.Sprintf("if (!%sis_minimal_varint(pos, buf)) {", libraryPrefix(structName)))
+			b.Indent()
+			b.P("return (false, pos, instance);")
+			b.Unindent()
+			b.P("}")
+		}
+		b.P("bool success;")
+		b.P("uint64 field_number;")
+		b.P("ProtobufLib.WireType wire_type;")
+		b.P("(success, pos, field_number, wire_type) = ProtobufLib.decode_key(pos, buf);")
+		b.P("if (!success) {")
+		b.Indent()
+		b.P("return (false, pos, instance);")
+		b.Unindent()
+		b.P("}")
+	}
 	b.P("")
 
-	b.P("// Check that the field number is within bounds")
-	b.P(fmt.Sprintf("if (field_number > %d) {", len(fields)))
-	b.Indent()
-	b.P("return (false, pos, instance);")
-	b.Unindent()
-	b.P("}")
-	b.P("")
+	if g.unknownFieldsFlag == unknownFieldsFlagReject {
+		b.P("// Check that the field number is within bounds")
+		b.P(fmt.Sprintf("if (field_number > %d) {", getMaxFieldNumber(fields)))
+		b.Indent()
+		b.P("return (false, pos, instance);")
+		b.Unindent()
+		b.P("}")
+		b.P("")
+	}
 
 	b.P("// Check that the field number is monotonically increasing")
 	if !g.allowNonMonotonicFields {
-		b.P("if (field_number <= previous_field_number) {")
+		// Repeated message fields and expanded numeric fields occur once per element
+		var repeatedConditions []string
+		for _, field := range fields {
+			if (isEmbeddedMessageField(field) && isFieldRepeated(field)) || g.isFieldExpanded(field) {
+				repeatedConditions = append(repeatedConditions, fmt.Sprintf("field_number != %d", field.GetNumber()))
+			}
+		}
+		if len(repeatedConditions) > 0 {
+			b.P(fmt.Sprintf("if (field_number < previous_field_number || (field_number == previous_field_number && %s)) {", strings.Join(repeatedConditions, " && ")))
+		} else {
+			b.P("if (field_number <= previous_field_number) {")
+		}
 		b.Indent()
 		b.P("return (false, pos, instance);")
 		b.Unindent()
@@ -62,6 +118,34 @@ func (g *Generator) generateMessageDecoder(structName string, fields []*descript
 	}
 	b.P("")
 
+	if g.unknownFieldsFlag != unknownFieldsFlagReject {
+		b.P("// Advance past fields unknown to this version of the schema")
+		b.P("if (!is_known_field(field_number)) {")
+		b.Indent()
+		b.P("(success, pos) = skip_field(pos, buf, wire_type);")
+		b.P("if (!success) {")
+		b.Indent()
+		b.P("return (false, pos, instance);")
+		b.Unindent()
+		b.P("}")
+		if g.unknownFieldsFlag == unknownFieldsFlagPreserve {
+			b.P("")
+			b.P("// Preserve the raw key and value")
+			b.P(fmt.Sprintf("bytes memory raw = %scopy_bytes(buf, key_pos, pos - key_pos);", libraryPrefix(structName)))
+			if g.solidityVersion.supportsBytesConcat() {
+				b.P("instance._unknown = bytes.concat(instance._unknown, raw);")
+			} else {
+				b.P("instance._unknown = abi.encodePacked(instance._unknown, raw);")
+			}
+		}
+		b.P("")
+		b.P("previous_field_number = field_number;")
+		b.P("continue;")
+		b.Unindent()
+		b.P("}")
+		b.P("")
+	}
+
 	b.P("// Check that the wire type is correct")
 	b.P("success = check_key(field_number, wire_type);")
 	b.P("if (!success) {")
@@ -72,7 +156,7 @@ func (g *Generator) generateMessageDecoder(structName string, fields []*descript
 	b.P("")
 
 	b.P("// Actually decode the field")
-	b.P("(success, pos) = decode_field(pos, buf, len, field_number, instance);")
+	b.P("(success, pos) = decode_field(pos, buf, len, field_number, instance, depth);")
 	b.P("if (!success) {")
 	b.Indent()
 	b.P("return (false, pos, instance);")
@@ -85,6 +169,20 @@ func (g *Generator) generateMessageDecoder(structName string, fields []*descript
 	b.P("}")
 	b.P("")
 
+	presenceGen.GenerateRequiredFieldsCheck(fields, fieldNameMap, b)
+
+	if g.validateOnDecode {
+		b.P("// Reject messages breaking their validation rules, embedded messages were checked by their own decoders")
+		b.P("bool valid;")
+		b.P("(valid, ) = validate_fields(instance);")
+		b.P("if (!valid) {")
+		b.Indent()
+		b.P("return (false, pos, instance);")
+		b.Unindent()
+		b.P("}")
+		b.P("")
+	}
+
 	b.P("return (true, pos, instance);")
 	b.Unindent()
 	b.P("}")
@@ -93,24 +191,134 @@ func (g *Generator) generateMessageDecoder(structName string, fields []*descript
 	return nil
 }
 
+// generateDepthCheck generates the revert of functions walking messages nested deeper than max_depth
+func (g *Generator) generateDepthCheck(structName string, b *WriteableBuffer) {
+	b.P("// Check that nested messages do not exceed the maximum depth")
+	b.P(fmt.Sprintf("if (depth > %d) {", g.maxDepth))
+	b.Indent()
+	if g.solidityVersion.supportsCustomErrors() {
+		b.P(fmt.Sprintf("revert %sMaxDepthExceeded(depth);", libraryPrefix(structName)))
+	} else {
+		b.P(`revert("MaxDepthExceeded");`)
+	}
+	b.Unindent()
+	b.P("}")
+}
+
+// generateBufferEncoder generates an encode function returning the encoding of a message in a new buffer of its exact size
+func (g *Generator) generateBufferEncoder(structName string, b *WriteableBuffer) {
+	b.P(fmt.Sprintf("function encode(%s memory instance) internal pure returns (bytes memory) {", structName))
+	b.Indent()
+	b.P("bytes memory buf = new bytes(encoded_size(instance));")
+	b.P("encode(0, buf, instance);")
+	b.P("return buf;")
+	b.Unindent()
+	b.P("}")
+	b.P("")
+}
+
+// generateMessageHash generates a function hashing the canonical encoding of a message with the configured hash function
+func (g *Generator) generateMessageHash(structName string, b *WriteableBuffer) {
+	hashExpression := "keccak256(encode(instance))"
+	switch g.hashFunctionFlag {
+	case hashFunctionFlagSha256:
+		hashExpression = "sha256(encode(instance))"
+	case hashFunctionFlagRipemd160:
+		// The 20 byte digest is left-aligned
+		hashExpression = "bytes32(ripemd160(encode(instance)))"
+	}
+
+	b.P(fmt.Sprintf("function hash(%s memory instance) internal pure returns (bytes32) {", structName))
+	b.Indent()
+	b.P(fmt.Sprintf("return %s;", hashExpression))
+	b.Unindent()
+	b.P("}")
+	b.P("")
+}
+
 // generateMessageEncoder generates the encoder functions for a message
 func (g *Generator) generateMessageEncoder(structName string, fields []*descriptorpb.FieldDescriptorProto, fieldNameMap map[int32]string, b *WriteableBuffer) error {
 	// Top-level encoder function
 	b.P(fmt.Sprintf("function encode(uint64 pos, bytes memory buf, %s memory instance) internal pure returns (uint64) {", structName))
 	b.Indent()
 
-	// Encode each field
-	for _, field := range fields {
+	// Encode each field, in field number order as canonical encoding requires
+	sortedFields := make([]*descriptorpb.FieldDescriptorProto, len(fields))
+	copy(sortedFields, fields)
+	sort.SliceStable(sortedFields, func(i, j int) bool {
+		return sortedFields[i].GetNumber() < sortedFields[j].GetNumber()
+	})
+	// Preserved unknown fields are merged in, each before the first known field numbered above it
+	preserveUnknown := g.unknownFieldsFlag == unknownFieldsFlagPreserve
+	if preserveUnknown {
+		b.P("uint64 unknown_pos = 0;")
+	}
+	for _, field := range sortedFields {
 		fieldNumber := field.GetNumber()
+		if preserveUnknown {
+			b.P(fmt.Sprintf("(pos, unknown_pos) = encode_unknown(pos, buf, instance, unknown_pos, %d);", fieldNumber))
+		}
 		b.P(fmt.Sprintf("pos = encode_%d(pos, buf, instance);", fieldNumber))
 	}
+	if preserveUnknown {
+		b.P("(pos, ) = encode_unknown(pos, buf, instance, unknown_pos, 0xFFFFFFFFFFFFFFFF);")
+	}
 
 	b.P("return pos;")
 	b.Unindent()
 	b.P("}")
 	b.P("")
 
+	err := NewSizeGenerator(g).GenerateEncodedSize(structName, fields, fieldNameMap, b)
+	if err != nil {
+		return err
+	}
+	g.generateBufferEncoder(structName, b)
+	g.generateMessageHash(structName, b)
+
+	if g.unknownFieldsFlag == unknownFieldsFlagPreserve {
+		// Unknown fields are held in field number order, as decoded, each with its key giving its number.
+		// They are written back verbatim, the run numbered below field_number at a time.
+		b.P(fmt.Sprintf("// %s._unknown, from unknown_pos up to the first field numbered field_number or above", structName))
+		b.P(fmt.Sprintf("function encode_unknown(uint64 pos, bytes memory buf, %s memory instance, uint64 unknown_pos, uint64 field_number) internal pure returns (uint64, uint64) {", structName))
+		b.Indent()
+		b.P("bool success;")
+		b.P("uint64 value_pos;")
+		b.P("uint64 number;")
+		b.P("ProtobufLib.WireType wire_type;")
+		b.P("uint64 end_pos = unknown_pos;")
+		b.P("while (end_pos < instance._unknown.length) {")
+		b.Indent()
+		b.P("(success, value_pos, number, wire_type) = ProtobufLib.decode_key(end_pos, instance._unknown);")
+		b.P("if (success && number >= field_number) {")
+		b.Indent()
+		b.P("break;")
+		b.Unindent()
+		b.P("}")
+		b.P("if (success) {")
+		b.Indent()
+		b.P("(success, value_pos) = skip_field(value_pos, instance._unknown, wire_type);")
+		b.Unindent()
+		b.P("}")
+		b.P("if (!success) {")
+		b.Indent()
+		b.P("// Bytes that are not a valid field are written out as they are")
+		b.P("end_pos = uint64(instance._unknown.length);")
+		b.P("break;")
+		b.Unindent()
+		b.P("}")
+		b.P("end_pos = value_pos;")
+		b.Unindent()
+		b.P("}")
+		b.P(fmt.Sprintf("pos = %swrite_bytes(buf, pos, instance._unknown, unknown_pos, end_pos - unknown_pos);", libraryPrefix(structName)))
+		b.P("return (pos, end_pos);")
+		b.Unindent()
+		b.P("}")
+		b.P("")
+	}
+
 	// Individual field encoders
+	presenceGen := NewPresenceGenerator(g)
 	for _, field := range fields {
 		fieldName := fieldNameMap[field.GetNumber()]
 		fieldDescriptorType := field.GetType()
@@ -123,9 +331,12 @@ func (g *Generator) generateMessageEncoder(structName string, fields []*descript
 		if isFieldRepeated(field) {
 			// Repeated field
 
-			if isFieldPacked(field) {
+			if g.isFieldPacked(field) {
 				// Packed repeated field
 
+				// Empty packed arrays are omitted, as canonical encoding requires, even where decoders accept them
+				packedGuard := fmt.Sprintf("if (instance.%s.length > 0) {", fieldName)
+
 				switch fieldDescriptorType {
 				case descriptorpb.FieldDescriptorProto_TYPE_ENUM:
 					// Packed repeated enum
@@ -135,7 +346,7 @@ func (g *Generator) generateMessageEncoder(structName string, fields []*descript
 						return err
 					}
 
-					b.P(fmt.Sprintf("if (instance.%s.length > 0) {", fieldName))
+					b.P(packedGuard)
 					b.Indent()
 					b.P("// Encode key")
 					b.P(fmt.Sprintf("pos = ProtobufLib.encode_key(%d, ProtobufLib.WireType.LengthDelimited, pos, buf);", fieldNumber))
@@ -171,7 +382,7 @@ func (g *Generator) generateMessageEncoder(structName string, fields []*descript
 						return errors.New(err.Error() + ": " + structName + "." + fieldName)
 					}
 
-					b.P(fmt.Sprintf("if (instance.%s.length > 0) {", fieldName))
+					b.P(packedGuard)
 					b.Indent()
 					b.P("// Encode key")
 					b.P(fmt.Sprintf("pos = ProtobufLib.encode_key(%d, ProtobufLib.WireType.LengthDelimited, pos, buf);", fieldNumber))
@@ -196,6 +407,32 @@ func (g *Generator) generateMessageEncoder(structName string, fields []*descript
 					b.Unindent()
 					b.P("}")
 				}
+			} else if g.isFieldExpanded(field) {
+				// Expanded repeated numeric field, each element following its own key
+
+				wireType, err := toSolWireType(field)
+				if err != nil {
+					return errors.New(err.Error() + ": " + structName + "." + fieldName)
+				}
+				fieldEncodeType, err := typeToEncodeSol(fieldDescriptorType)
+				if err != nil {
+					return errors.New(err.Error() + ": " + structName + "." + fieldName)
+				}
+				element := fmt.Sprintf("instance.%s[i]", fieldName)
+				if fieldDescriptorType == descriptorpb.FieldDescriptorProto_TYPE_ENUM {
+					element = fmt.Sprintf("int32(%s)", element)
+				}
+
+				b.P(fmt.Sprintf("for (uint64 i = 0; i < instance.%s.length; i++) {", fieldName))
+				b.Indent()
+				b.P("// Encode key")
+				b.P(fmt.Sprintf("pos = ProtobufLib.encode_key(%d, %s, pos, buf);", fieldNumber, wireType))
+				b.P("")
+
+				b.P("// Encode element")
+				b.P(fmt.Sprintf("pos = %s(pos, buf, %s);", fieldEncodeType, element))
+				b.Unindent()
+				b.P("}")
 			} else {
 				// Non-packed repeated field (i.e. message, string, or bytes)
 
@@ -242,7 +479,7 @@ func (g *Generator) generateMessageEncoder(structName string, fields []*descript
 					b.P("")
 
 					b.P("// Encode message")
-					b.P(fmt.Sprintf("pos = %sCodec.encode(pos, buf, instance.%s[i]);", fieldTypeName, fieldName))
+					b.P(fmt.Sprintf("pos = %s.encode(pos, buf, instance.%s[i]);", CodecLibraryName(fieldTypeName), fieldName))
 					b.P("")
 
 					b.P("// Encode length")
@@ -255,6 +492,19 @@ func (g *Generator) generateMessageEncoder(structName string, fields []*descript
 		} else {
 			// Optional field (i.e. not repeated)
 
+			if g.requiredFields[field] {
+				// Decoders reject messages missing a required field, so none is encoded
+				b.P(fmt.Sprintf("if (!instance.%s) {", presenceMemberName(fieldName)))
+				b.Indent()
+				if g.solidityVersion.supportsCustomErrors() {
+					b.P(fmt.Sprintf("revert %sMissingRequiredField(%d);", libraryPrefix(structName), fieldNumber))
+				} else {
+					b.P(`revert("MissingRequiredField");`)
+				}
+				b.Unindent()
+				b.P("}")
+			}
+
 			switch fieldDescriptorType {
 			case descriptorpb.FieldDescriptorProto_TYPE_ENUM:
 				fieldTypeName, err := g.getSolTypeName(field)
@@ -262,7 +512,7 @@ func (g *Generator) generateMessageEncoder(structName string, fields []*descript
 					return err
 				}
 
-				b.P(fmt.Sprintf("if (instance.%s != %s(0)) {", fieldName, fieldTypeName))
+				b.P(fmt.Sprintf("if (%s) {", presenceGen.encodeCondition(field, fieldName, fmt.Sprintf("instance.%s != %s(0)", fieldName, fieldTypeName))))
 				b.Indent()
 				b.P("// Encode key")
 				b.P(fmt.Sprintf("pos = ProtobufLib.encode_key(%d, ProtobufLib.WireType.Varint, pos, buf);", fieldNumber))
@@ -273,12 +523,28 @@ func (g *Generator) generateMessageEncoder(structName string, fields []*descript
 				b.Unindent()
 				b.P("}")
 			case descriptorpb.FieldDescriptorProto_TYPE_MESSAGE:
+				if g.isRecursiveField(field) {
+					// Recursive fields already hold the encoded message
+					b.P(fmt.Sprintf("if (%s) {", presenceGen.encodeCondition(field, fieldName, fmt.Sprintf("instance.%s.length > 0", fieldName))))
+					b.Indent()
+					b.P("// Encode key")
+					b.P(fmt.Sprintf("pos = ProtobufLib.encode_key(%d, ProtobufLib.WireType.LengthDelimited, pos, buf);", fieldNumber))
+					b.P("")
+
+					b.P("// Encode message")
+					b.P(fmt.Sprintf("pos = ProtobufLib.encode_bytes(pos, buf, instance.%s);", fieldName))
+					b.Unindent()
+					b.P("}")
+					break
+				}
+
 				fieldTypeName, err := g.getSolTypeName(field)
 				if err != nil {
 					return err
 				}
 
-				b.P(fmt.Sprintf("if (instance.%s.value.length > 0) {", fieldName))
+				// Messages without any field to encode are absent
+				b.P(fmt.Sprintf("if (%s) {", presenceGen.encodeCondition(field, fieldName, fmt.Sprintf("%s.encoded_size(instance.%s) > 0", CodecLibraryName(fieldTypeName), fieldName))))
 				b.Indent()
 				b.P("// Encode key")
 				b.P(fmt.Sprintf("pos = ProtobufLib.encode_key(%d, ProtobufLib.WireType.LengthDelimited, pos, buf);", fieldNumber))
@@ -290,7 +556,7 @@ func (g *Generator) generateMessageEncoder(structName string, fields []*descript
 				b.P("")
 
 				b.P("// Encode message")
-				b.P(fmt.Sprintf("pos = %sCodec.encode(pos, buf, instance.%s);", fieldTypeName, fieldName))
+				b.P(fmt.Sprintf("pos = %s.encode(pos, buf, instance.%s);", CodecLibraryName(fieldTypeName), fieldName))
 				b.P("")
 
 				b.P("// Encode length")
@@ -321,7 +587,7 @@ func (g *Generator) generateMessageEncoder(structName string, fields []*descript
 					descriptorpb.FieldDescriptorProto_TYPE_SFIXED64,
 					descriptorpb.FieldDescriptorProto_TYPE_FLOAT,
 					descriptorpb.FieldDescriptorProto_TYPE_DOUBLE:
-					b.P(fmt.Sprintf("if (instance.%s != 0) {", fieldName))
+					b.P(fmt.Sprintf("if (%s) {", presenceGen.encodeCondition(field, fieldName, fmt.Sprintf("instance.%s != 0", fieldName))))
 					b.Indent()
 					b.P("// Encode key")
 					b.P(fmt.Sprintf("pos = ProtobufLib.encode_key(%d, ProtobufLib.WireType.Varint, pos, buf);", fieldNumber))
@@ -332,7 +598,7 @@ func (g *Generator) generateMessageEncoder(structName string, fields []*descript
 					b.Unindent()
 					b.P("}")
 				case descriptorpb.FieldDescriptorProto_TYPE_BOOL:
-					b.P(fmt.Sprintf("if (instance.%s != false) {", fieldName))
+					b.P(fmt.Sprintf("if (%s) {", presenceGen.encodeCondition(field, fieldName, fmt.Sprintf("instance.%s != false", fieldName))))
 					b.Indent()
 					b.P("// Encode key")
 					b.P(fmt.Sprintf("pos = ProtobufLib.encode_key(%d, ProtobufLib.WireType.Varint, pos, buf);", fieldNumber))
@@ -342,26 +608,61 @@ func (g *Generator) generateMessageEncoder(structName string, fields []*descript
 					b.P(fmt.Sprintf("pos = %s(pos, buf, instance.%s);", fieldEncodeType, fieldName))
 					b.Unindent()
 					b.P("}")
-				case descriptorpb.FieldDescriptorProto_TYPE_STRING:
-					b.P(fmt.Sprintf("if (bytes(instance.%s).length > 0) {", fieldName))
-					b.Indent()
-					b.P("// Encode key")
-					b.P(fmt.Sprintf("pos = ProtobufLib.encode_key(%d, ProtobufLib.WireType.LengthDelimited, pos, buf);", fieldNumber))
-					b.P("")
-
-					b.P("// Encode value")
-					b.P(fmt.Sprintf("pos = %s(pos, buf, instance.%s);", fieldEncodeType, fieldName))
-					b.Unindent()
-					b.P("}")
-				case descriptorpb.FieldDescriptorProto_TYPE_BYTES:
-					b.P(fmt.Sprintf("if (instance.%s.length > 0) {", fieldName))
+				case descriptorpb.FieldDescriptorProto_TYPE_STRING,
+					descriptorpb.FieldDescriptorProto_TYPE_BYTES:
+					solType, fixedSize, err := fieldTypeOverride(field)
+					if err != nil {
+						return errors.New(err.Error() + ": " + structName + "." + fieldName)
+					}
+					if fixedSize > 0 {
+						// Fixed size values are encoded as exactly their bytes, and omitted when zero
+						b.P(fmt.Sprintf("if (%s) {", presenceGen.encodeCondition(field, fieldName, fmt.Sprintf("instance.%s != %s(0)", fieldName, solType))))
+						b.Indent()
+						b.P("// Encode key")
+						b.P(fmt.Sprintf("pos = ProtobufLib.encode_key(%d, ProtobufLib.WireType.LengthDelimited, pos, buf);", fieldNumber))
+						b.P("")
+
+						b.P("// Encode value")
+						b.P(fmt.Sprintf("pos = ProtobufLib.encode_bytes(pos, buf, abi.encodePacked(instance.%s));", fieldName))
+						b.Unindent()
+						b.P("}")
+						break
+					}
+					if isLargeIntegerType(solType) {
+						// Decimal strings of integers are omitted when zero
+						b.P(fmt.Sprintf("if (%s) {", presenceGen.encodeCondition(field, fieldName, fmt.Sprintf("instance.%s != 0", fieldName))))
+						b.Indent()
+						b.P("// Encode key")
+						b.P(fmt.Sprintf("pos = ProtobufLib.encode_key(%d, ProtobufLib.WireType.LengthDelimited, pos, buf);", fieldNumber))
+						b.P("")
+
+						b.P("// Encode value")
+						b.P(fmt.Sprintf("pos = ProtobufLib.encode_bytes(pos, buf, %sencode_decimal_%s(instance.%s));", libraryPrefix(structName), solType, fieldName))
+						b.Unindent()
+						b.P("}")
+						break
+					}
+					if fieldDescriptorType == descriptorpb.FieldDescriptorProto_TYPE_STRING && solType != "bytes" {
+						b.P(fmt.Sprintf("if (%s) {", presenceGen.encodeCondition(field, fieldName, fmt.Sprintf("bytes(instance.%s).length > 0", fieldName))))
+						b.Indent()
+						b.P("// Encode key")
+						b.P(fmt.Sprintf("pos = ProtobufLib.encode_key(%d, ProtobufLib.WireType.LengthDelimited, pos, buf);", fieldNumber))
+						b.P("")
+
+						b.P("// Encode value")
+						b.P(fmt.Sprintf("pos = %s(pos, buf, instance.%s);", fieldEncodeType, fieldName))
+						b.Unindent()
+						b.P("}")
+						break
+					}
+					b.P(fmt.Sprintf("if (%s) {", presenceGen.encodeCondition(field, fieldName, fmt.Sprintf("instance.%s.length > 0", fieldName))))
 					b.Indent()
 					b.P("// Encode key")
 					b.P(fmt.Sprintf("pos = ProtobufLib.encode_key(%d, ProtobufLib.WireType.LengthDelimited, pos, buf);", fieldNumber))
 					b.P("")
 
 					b.P("// Encode value")
-					b.P(fmt.Sprintf("pos = %s(pos, buf, instance.%s);", fieldEncodeType, fieldName))
+					b.P(fmt.Sprintf("pos = ProtobufLib.encode_bytes(pos, buf, instance.%s);", fieldName))
 					b.Unindent()
 					b.P("}")
 				default:
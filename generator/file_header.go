@@ -1,20 +1,167 @@
 package generator
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"math"
+	"sort"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
 )
 
+// BuildMetadata describes the inputs a Solidity file was generated from, for reproducible builds
+type BuildMetadata struct {
+	SourcePath string // Path of the source .proto file
+	SchemaHash string // Hex-encoded SHA-256 of the canonical encoding of the source FileDescriptorProto
+	Parameters string // Effective plugin parameters
+}
+
+// NewBuildMetadata computes the build metadata of a proto file.
+// Source code info (comments and spans) is left out of the hash, so only schema changes alter it.
+func NewBuildMetadata(protoFile *descriptorpb.FileDescriptorProto, parameters string) (*BuildMetadata, error) {
+	schema := proto.Clone(protoFile).(*descriptorpb.FileDescriptorProto)
+	schema.SourceCodeInfo = nil
+
+	encoded, err := canonicalEncoding(nil, schema.ProtoReflect())
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode descriptor of %s: %w", protoFile.GetName(), err)
+	}
+	hash := sha256.Sum256(encoded)
+
+	return &BuildMetadata{
+		SourcePath: protoFile.GetName(),
+		SchemaHash: hex.EncodeToString(hash[:]),
+		Parameters: parameters,
+	}, nil
+}
+
+// canonicalEncoding appends the canonical encoding of a message to b, so that schema hashes do not
+// depend on the protobuf library serializing the descriptor. It is the protobuf binary encoding with:
+//   - every field, known or unknown, written in ascending field number order
+//   - repeated fields written unpacked, one record per element in their order
+//   - embedded messages encoded canonically themselves, unknown ones kept as the raw bytes they were given
+func canonicalEncoding(b []byte, m protoreflect.Message) ([]byte, error) {
+	type record struct {
+		number protowire.Number
+		field  protoreflect.FieldDescriptor // Nil for unknown fields
+		value  protoreflect.Value
+		raw    []byte // Tag and value of unknown fields
+	}
+	var records []record
+	m.Range(func(field protoreflect.FieldDescriptor, value protoreflect.Value) bool {
+		records = append(records, record{number: field.Number(), field: field, value: value})
+		return true
+	})
+	for unknown := m.GetUnknown(); len(unknown) > 0; {
+		number, _, n := protowire.ConsumeField(unknown)
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		records = append(records, record{number: number, raw: unknown[:n]})
+		unknown = unknown[n:]
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].number < records[j].number
+	})
+
+	var err error
+	for _, r := range records {
+		switch {
+		case r.field == nil:
+			b = append(b, r.raw...)
+		case r.field.IsList():
+			list := r.value.List()
+			for i := 0; i < list.Len(); i++ {
+				if b, err = appendCanonicalValue(b, r.field, list.Get(i)); err != nil {
+					return nil, err
+				}
+			}
+		case r.field.IsMap():
+			return nil, fmt.Errorf("map field %s is not supported", r.field.FullName())
+		default:
+			if b, err = appendCanonicalValue(b, r.field, r.value); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return b, nil
+}
+
+// appendCanonicalValue appends a single record of a field to b, as part of canonicalEncoding
+func appendCanonicalValue(b []byte, field protoreflect.FieldDescriptor, value protoreflect.Value) ([]byte, error) {
+	number := field.Number()
+	switch field.Kind() {
+	case protoreflect.BoolKind:
+		b = protowire.AppendTag(b, number, protowire.VarintType)
+		b = protowire.AppendVarint(b, protowire.EncodeBool(value.Bool()))
+	case protoreflect.EnumKind:
+		b = protowire.AppendTag(b, number, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(value.Enum()))
+	case protoreflect.Int32Kind, protoreflect.Int64Kind:
+		b = protowire.AppendTag(b, number, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(value.Int()))
+	case protoreflect.Sint32Kind, protoreflect.Sint64Kind:
+		b = protowire.AppendTag(b, number, protowire.VarintType)
+		b = protowire.AppendVarint(b, protowire.EncodeZigZag(value.Int()))
+	case protoreflect.Uint32Kind, protoreflect.Uint64Kind:
+		b = protowire.AppendTag(b, number, protowire.VarintType)
+		b = protowire.AppendVarint(b, value.Uint())
+	case protoreflect.Fixed32Kind:
+		b = protowire.AppendTag(b, number, protowire.Fixed32Type)
+		b = protowire.AppendFixed32(b, uint32(value.Uint()))
+	case protoreflect.Sfixed32Kind:
+		b = protowire.AppendTag(b, number, protowire.Fixed32Type)
+		b = protowire.AppendFixed32(b, uint32(value.Int()))
+	case protoreflect.FloatKind:
+		b = protowire.AppendTag(b, number, protowire.Fixed32Type)
+		b = protowire.AppendFixed32(b, math.Float32bits(float32(value.Float())))
+	case protoreflect.Fixed64Kind:
+		b = protowire.AppendTag(b, number, protowire.Fixed64Type)
+		b = protowire.AppendFixed64(b, value.Uint())
+	case protoreflect.Sfixed64Kind:
+		b = protowire.AppendTag(b, number, protowire.Fixed64Type)
+		b = protowire.AppendFixed64(b, uint64(value.Int()))
+	case protoreflect.DoubleKind:
+		b = protowire.AppendTag(b, number, protowire.Fixed64Type)
+		b = protowire.AppendFixed64(b, math.Float64bits(value.Float()))
+	case protoreflect.StringKind:
+		b = protowire.AppendTag(b, number, protowire.BytesType)
+		b = protowire.AppendString(b, value.String())
+	case protoreflect.BytesKind:
+		b = protowire.AppendTag(b, number, protowire.BytesType)
+		b = protowire.AppendBytes(b, value.Bytes())
+	case protoreflect.MessageKind:
+		embedded, err := canonicalEncoding(nil, value.Message())
+		if err != nil {
+			return nil, err
+		}
+		b = protowire.AppendTag(b, number, protowire.BytesType)
+		b = protowire.AppendBytes(b, embedded)
+	default:
+		return nil, fmt.Errorf("field %s of kind %s is not supported", field.FullName(), field.Kind())
+	}
+	return b, nil
+}
+
 // FileHeaderGenerator handles generation of file headers and metadata
 type FileHeaderGenerator struct {
-	versionString string
-	licenseString string
+	versionString   string
+	licenseString   string
+	solidityVersion solidityVersion
+	buildMetadata   *BuildMetadata
 }
 
 // NewFileHeaderGenerator creates a new file header generator
-func NewFileHeaderGenerator(versionString, licenseString string) *FileHeaderGenerator {
+func NewFileHeaderGenerator(versionString, licenseString string, solidityVersion solidityVersion, buildMetadata *BuildMetadata) *FileHeaderGenerator {
 	return &FileHeaderGenerator{
-		versionString: versionString,
-		licenseString: licenseString,
+		versionString:   versionString,
+		licenseString:   licenseString,
+		solidityVersion: solidityVersion,
+		buildMetadata:   buildMetadata,
 	}
 }
 
@@ -22,7 +169,12 @@ func NewFileHeaderGenerator(versionString, licenseString string) *FileHeaderGene
 func (fhg *FileHeaderGenerator) GenerateFileHeader(b *WriteableBuffer) {
 	b.P(fmt.Sprintf("// File automatically generated by protoc-gen-sol %s", fhg.versionString))
 	b.P(fmt.Sprintf("// SPDX-License-Identifier: %s", fhg.licenseString))
-	b.P("pragma solidity ^0.8.19;")
+	if fhg.buildMetadata != nil {
+		b.P(fmt.Sprintf("// Source: %s", fhg.buildMetadata.SourcePath))
+		b.P(fmt.Sprintf("// Schema SHA-256: %s", fhg.buildMetadata.SchemaHash))
+		b.P(fmt.Sprintf("// Parameters: %s", fhg.buildMetadata.Parameters))
+	}
+	fhg.solidityVersion.generatePragmas(b)
 	b.P0()
 }
 
@@ -32,3 +184,13 @@ func (fhg *FileHeaderGenerator) GeneratePackageComment(packageName string, b *Wr
 		b.P(fmt.Sprintf("// Package: %s", packageName))
 	}
 }
+
+// GenerateSchemaHash generates the SCHEMA_HASH constant of a library
+func (fhg *FileHeaderGenerator) GenerateSchemaHash(b *WriteableBuffer) {
+	if fhg.buildMetadata == nil {
+		return
+	}
+	b.P("// SHA-256 of the canonical encoding of the FileDescriptorProto this library was generated from")
+	b.P(fmt.Sprintf("bytes32 internal constant SCHEMA_HASH = 0x%s;", fhg.buildMetadata.SchemaHash))
+	b.P0()
+}
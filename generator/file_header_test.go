@@ -0,0 +1,82 @@
+package generator
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func TestCanonicalEncodingMatchesProtobufEncoding(t *testing.T) {
+	file := proto3OptionalFile()
+	encoded, err := canonicalEncoding(nil, file.ProtoReflect())
+	if err != nil {
+		t.Fatalf("canonicalEncoding: %v", err)
+	}
+	want, err := proto.MarshalOptions{Deterministic: true}.Marshal(file)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if !bytes.Equal(encoded, want) {
+		t.Errorf("canonicalEncoding = %x, want %x", encoded, want)
+	}
+}
+
+func TestCanonicalEncodingOrdersUnknownFields(t *testing.T) {
+	// Field 17 of FieldOptions is unknown to this protobuf version, and sorts between its known fields 3 and 999
+	options := &descriptorpb.FieldOptions{
+		Deprecated:          proto.Bool(true),
+		UninterpretedOption: []*descriptorpb.UninterpretedOption{{IdentifierValue: proto.String("x")}},
+	}
+	unknown := protowire.AppendTag(nil, 17, protowire.VarintType)
+	unknown = protowire.AppendVarint(unknown, 1)
+	options.ProtoReflect().SetUnknown(unknown)
+
+	encoded, err := canonicalEncoding(nil, options.ProtoReflect())
+	if err != nil {
+		t.Fatalf("canonicalEncoding: %v", err)
+	}
+	want := protowire.AppendTag(nil, 3, protowire.VarintType)
+	want = protowire.AppendVarint(want, 1)
+	want = append(want, unknown...)
+	want = protowire.AppendTag(want, 999, protowire.BytesType)
+	want = protowire.AppendBytes(want, []byte{3<<3 | byte(protowire.BytesType), 1, 'x'})
+	if !bytes.Equal(encoded, want) {
+		t.Errorf("canonicalEncoding = %x, want %x", encoded, want)
+	}
+}
+
+func TestBuildMetadataHeaderAndSchemaHash(t *testing.T) {
+	// The canonical encoding is pinned, so the hash of a schema only changes with the schema
+	const schemaHash = "993370615c8552fbc283566952354d05a93980ae56cbcd1c7e9ee7bd9c2a4ac0"
+
+	file := proto3OptionalFile()
+	file.SourceCodeInfo = &descriptorpb.SourceCodeInfo{}
+	metadata, err := NewBuildMetadata(file, "")
+	if err != nil {
+		t.Fatalf("NewBuildMetadata: %v", err)
+	}
+	if metadata.SchemaHash != schemaHash {
+		t.Errorf("SchemaHash = %s, want %s", metadata.SchemaHash, schemaHash)
+	}
+
+	response := generateWithParameter(t, "build_metadata=true", file)
+	if len(response.GetFile()) != 1 {
+		t.Fatalf("generated %d files, want 1", len(response.GetFile()))
+	}
+	content := response.GetFile()[0].GetContent()
+	for _, want := range []string{
+		"// Source: optional.proto\n",
+		"// Schema SHA-256: " + schemaHash + "\n",
+		"// Parameters: ",
+		"bytes32 internal constant SCHEMA_HASH = 0x" + schemaHash + ";",
+		"bytes32 internal constant SCHEMA_HASH = Optional.SCHEMA_HASH;",
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("generated file does not contain %q", want)
+		}
+	}
+}
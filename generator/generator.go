@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"strconv"
 	"strings"
 
 	"google.golang.org/protobuf/proto"
@@ -11,8 +12,8 @@ import (
 	"google.golang.org/protobuf/types/pluginpb"
 )
 
-// SolidityVersionString is the Solidity version specifier.
-const SolidityVersionString = ">=0.6.0 <8.0.0"
+// SolidityVersionString is the default Solidity version specifier.
+const SolidityVersionString = "^0.8.19"
 
 // SolidityABIString indicates ABIEncoderV2 use.
 const SolidityABIString = "pragma experimental ABIEncoderV2;"
@@ -64,15 +65,97 @@ func toGenerateFlag(s string) (generateFlag, error) {
 	return generateFlagAll, fmt.Errorf("unknown generate flag %s, allowed values are <all, decoder, encoder>", s)
 }
 
+type unknownFieldsFlag string
+
+const (
+	unknownFieldsFlagReject   unknownFieldsFlag = "reject"
+	unknownFieldsFlagSkip     unknownFieldsFlag = "skip"
+	unknownFieldsFlagPreserve unknownFieldsFlag = "preserve"
+)
+
+func fromUnknownFieldsFlag(f unknownFieldsFlag) string {
+	return string(f)
+}
+
+func toUnknownFieldsFlag(s string) (unknownFieldsFlag, error) {
+	switch s {
+	case fromUnknownFieldsFlag(unknownFieldsFlagReject):
+		return unknownFieldsFlagReject, nil
+	case fromUnknownFieldsFlag(unknownFieldsFlagSkip):
+		return unknownFieldsFlagSkip, nil
+	case fromUnknownFieldsFlag(unknownFieldsFlagPreserve):
+		return unknownFieldsFlagPreserve, nil
+	}
+
+	return unknownFieldsFlagReject, fmt.Errorf("unknown unknown_fields flag %s, allowed values are <reject, skip, preserve>", s)
+}
+
+type optimizeFlag string
+
+const (
+	optimizeFlagReference optimizeFlag = "reference"
+	optimizeFlagGas       optimizeFlag = "gas"
+)
+
+func fromOptimizeFlag(f optimizeFlag) string {
+	return string(f)
+}
+
+func toOptimizeFlag(s string) (optimizeFlag, error) {
+	switch s {
+	case fromOptimizeFlag(optimizeFlagReference):
+		return optimizeFlagReference, nil
+	case fromOptimizeFlag(optimizeFlagGas):
+		return optimizeFlagGas, nil
+	}
+
+	return optimizeFlagReference, fmt.Errorf("unknown optimize flag %s, allowed values are <reference, gas>", s)
+}
+
+type hashFunctionFlag string
+
+const (
+	hashFunctionFlagKeccak256 hashFunctionFlag = "keccak256"
+	hashFunctionFlagSha256    hashFunctionFlag = "sha256"
+	hashFunctionFlagRipemd160 hashFunctionFlag = "ripemd160"
+)
+
+func fromHashFunctionFlag(f hashFunctionFlag) string {
+	return string(f)
+}
+
+func toHashFunctionFlag(s string) (hashFunctionFlag, error) {
+	switch s {
+	case fromHashFunctionFlag(hashFunctionFlagKeccak256):
+		return hashFunctionFlagKeccak256, nil
+	case fromHashFunctionFlag(hashFunctionFlagSha256):
+		return hashFunctionFlagSha256, nil
+	case fromHashFunctionFlag(hashFunctionFlagRipemd160):
+		return hashFunctionFlagRipemd160, nil
+	}
+
+	return hashFunctionFlagKeccak256, fmt.Errorf("unknown hash_function flag %s, allowed values are <keccak256, sha256, ripemd160>", s)
+}
+
+// maxDecodingDepth is the default and largest max_depth.
+// Each nesting level keeps up to three internal call frames on the EVM stack (decode, decode_field and, with
+// optimize=gas, the field decoder), which take about 48 of its 1024 slots with unknown_fields=preserve. 17 levels
+// (depths 0 to 16) take about 820 slots, leaving the rest to the frames of the calling contract, so deeper payloads
+// revert with MaxDepthExceeded before they can overflow the stack.
+const maxDecodingDepth = 16
+
 // Generator generates Solidity code from .proto files.
 type Generator struct {
 	request   *pluginpb.CodeGeneratorRequest
 	enumMaxes map[string]int
 
-	versionString string
-	licenseString string
-	compileFlag   compileFlag
-	generateFlag  generateFlag
+	versionString     string
+	licenseString     string
+	compileFlag       compileFlag
+	generateFlag      generateFlag
+	unknownFieldsFlag unknownFieldsFlag
+	optimizeFlag      optimizeFlag
+	hashFunctionFlag  hashFunctionFlag
 
 	// Enhanced features for PostFiat support
 	helperMessages map[string]map[string]*descriptorpb.DescriptorProto // package -> message name -> descriptor (only wrapper messages)
@@ -83,8 +166,25 @@ type Generator struct {
 	// Track nested message name mappings: original nested name -> flattened name
 	messageMappings map[string]string
 
-	// Global message registry for type resolution
+	// Global message registry for type resolution, including nested messages
 	messageRegistry map[string]*descriptorpb.DescriptorProto
+	// Message full name -> struct name, with nested messages flattened to Outer_Inner
+	messageStructNames map[string]string
+	// Singular message fields on a type cycle, held as serialized bytes
+	recursiveFields map[*descriptorpb.FieldDescriptorProto]bool
+	// Message full names whose struct reaches a cycle of structs, which the ABI coder cannot handle
+	abiRecursiveMessages map[string]bool
+	// Singular fields tracking whether they were set: those of proto2 files, proto3 optional fields and those with
+	// explicit presence in editions files
+	presenceFields map[*descriptorpb.FieldDescriptorProto]bool
+	// Fields whose absence fails decoding, proto2 required fields and LEGACY_REQUIRED fields of editions files
+	requiredFields map[*descriptorpb.FieldDescriptorProto]bool
+	// Repeated fields of editions files packed by their repeated_field_encoding feature
+	packedFields map[*descriptorpb.FieldDescriptorProto]bool
+	// Repeated numeric fields of proto2 files without [packed = true], encoded with one key per element
+	expandedFields map[*descriptorpb.FieldDescriptorProto]bool
+	// Message fields of editions files encoded as groups by their message_encoding feature
+	delimitedFields map[*descriptorpb.FieldDescriptorProto]bool
 
 	// Track successfully generated structs to ensure codec generation matches
 	successfullyGeneratedStructs map[string]bool
@@ -94,7 +194,17 @@ type Generator struct {
 	strictEnumValidation        bool
 	allowEmptyPackedArrays      bool
 	allowNonMonotonicFields     bool
-	protobufLibImportPath       string // Import path for ProtobufLib.sol
+	strictCanonical             bool
+	maxDepth                    int             // Maximum nesting depth accepted by decoders and is_canonical
+	buildMetadata               bool            // Emit source, schema hash and parameters for reproducible builds
+	storageHelpers              bool            // Emit store functions copying memory structs to storage
+	eip712                      bool            // Emit EIP-712 type hashes, hash_struct functions and type definitions
+	events                      bool            // Emit events with the scalar fields of messages, and helpers emitting them
+	validate                    bool            // Emit validation functions from buf.validate and protoc-gen-validate rules
+	validateOnDecode            bool            // Make decoders reject messages breaking their validation rules
+	solidityVersion             solidityVersion // Target compiler version, selects the pragma and generated idioms
+	evmVersion                  evmVersion      // Target EVM version, enables instructions of recent hard forks
+	protobufLibImportPath       string          // Import path for ProtobufLib.sol
 
 	// Track Google protobuf generation to avoid duplicates
 	googleProtobufGenerated bool
@@ -111,6 +221,7 @@ func New(request *pluginpb.CodeGeneratorRequest, versionString string) *Generato
 	g.enumMappings = make(map[string]string)
 	g.messageMappings = make(map[string]string)
 	g.messageRegistry = make(map[string]*descriptorpb.DescriptorProto)
+	g.messageStructNames = make(map[string]string)
 	g.successfullyGeneratedStructs = make(map[string]bool)
 
 	g.versionString = versionString
@@ -118,12 +229,24 @@ func New(request *pluginpb.CodeGeneratorRequest, versionString string) *Generato
 
 	g.compileFlag = compileFlagCompile
 	g.generateFlag = generateFlagDecoder
+	g.unknownFieldsFlag = unknownFieldsFlagReject
+	g.optimizeFlag = optimizeFlagReference
+	g.hashFunctionFlag = hashFunctionFlagKeccak256
 
 	// Default configuration
 	g.strictFieldNumberValidation = false // Allow empty messages by default
 	g.strictEnumValidation = true
 	g.allowEmptyPackedArrays = false
 	g.allowNonMonotonicFields = false
+	g.strictCanonical = false // Opt-in, so payloads of relaxed producers keep decoding
+	g.maxDepth = maxDecodingDepth
+	g.buildMetadata = false
+	g.storageHelpers = false
+	g.eip712 = false
+	g.events = false
+	g.validate = false
+	g.validateOnDecode = false
+	g.solidityVersion, _ = toSolidityVersion(SolidityVersionString)
 	g.protobufLibImportPath = "@protobuf3-solidity-lib/contracts/ProtobufLib.sol" // Use package path by default
 
 	return g
@@ -137,7 +260,10 @@ func (g *Generator) ParseParameters() error {
 	}
 
 	for _, parameter := range strings.Split(parameterString, ",") {
-		keyvalue := strings.Split(parameter, "=")
+		keyvalue := strings.SplitN(parameter, "=", 2)
+		if len(keyvalue) != 2 {
+			return fmt.Errorf("parameter %s must be given as key=value", parameter)
+		}
 		key, value := keyvalue[0], keyvalue[1]
 
 		switch key {
@@ -161,6 +287,24 @@ func (g *Generator) ParseParameters() error {
 				return err
 			}
 			g.generateFlag = flag
+		case "unknown_fields":
+			flag, err := toUnknownFieldsFlag(value)
+			if err != nil {
+				return err
+			}
+			g.unknownFieldsFlag = flag
+		case "optimize":
+			flag, err := toOptimizeFlag(value)
+			if err != nil {
+				return err
+			}
+			g.optimizeFlag = flag
+		case "hash_function":
+			flag, err := toHashFunctionFlag(value)
+			if err != nil {
+				return err
+			}
+			g.hashFunctionFlag = flag
 		case "strict_field_numbers":
 			if value == "false" {
 				g.strictFieldNumberValidation = false
@@ -193,6 +337,83 @@ func (g *Generator) ParseParameters() error {
 			} else {
 				return errors.New("allow_non_monotonic_fields must be 'true' or 'false'")
 			}
+		case "strict_canonical":
+			if value == "true" {
+				g.strictCanonical = true
+			} else if value == "false" {
+				g.strictCanonical = false
+			} else {
+				return errors.New("strict_canonical must be 'true' or 'false'")
+			}
+		case "max_depth":
+			maxDepth, err := strconv.Atoi(value)
+			if err != nil || maxDepth < 1 {
+				return errors.New("max_depth must be a positive integer, unbounded nesting overflows the EVM stack")
+			}
+			if maxDepth > maxDecodingDepth {
+				return fmt.Errorf("max_depth must be at most %d, deeper nesting overflows the EVM stack before the limit is reached", maxDecodingDepth)
+			}
+			g.maxDepth = maxDepth
+		case "solidity_version":
+			version, err := toSolidityVersion(value)
+			if err != nil {
+				return err
+			}
+			g.solidityVersion = version
+		case "evm_version":
+			version, err := toEVMVersion(value)
+			if err != nil {
+				return err
+			}
+			g.evmVersion = version
+		case "build_metadata":
+			if value == "true" {
+				g.buildMetadata = true
+			} else if value == "false" {
+				g.buildMetadata = false
+			} else {
+				return errors.New("build_metadata must be 'true' or 'false'")
+			}
+		case "storage_helpers":
+			if value == "true" {
+				g.storageHelpers = true
+			} else if value == "false" {
+				g.storageHelpers = false
+			} else {
+				return errors.New("storage_helpers must be 'true' or 'false'")
+			}
+		case "eip712":
+			if value == "true" {
+				g.eip712 = true
+			} else if value == "false" {
+				g.eip712 = false
+			} else {
+				return errors.New("eip712 must be 'true' or 'false'")
+			}
+		case "events":
+			if value == "true" {
+				g.events = true
+			} else if value == "false" {
+				g.events = false
+			} else {
+				return errors.New("events must be 'true' or 'false'")
+			}
+		case "validate":
+			if value == "true" {
+				g.validate = true
+			} else if value == "false" {
+				g.validate = false
+			} else {
+				return errors.New("validate must be 'true' or 'false'")
+			}
+		case "validate_on_decode":
+			if value == "true" {
+				g.validateOnDecode = true
+			} else if value == "false" {
+				g.validateOnDecode = false
+			} else {
+				return errors.New("validate_on_decode must be 'true' or 'false'")
+			}
 		case "protobuf_lib_import":
 			// Use the provided import path as-is
 			// This allows for both local paths (ProtobufLib.sol) and package paths (@protobuf3-solidity-lib/contracts/ProtobufLib.sol)
@@ -205,12 +426,38 @@ func (g *Generator) ParseParameters() error {
 		}
 	}
 
+	if g.validateOnDecode && !g.validate {
+		return errors.New("validate_on_decode requires validate=true")
+	}
+
 	return nil
 }
 
+// featureSupportsEditions is CodeGeneratorResponse.FEATURE_SUPPORTS_EDITIONS, which this protobuf version does not define
+const featureSupportsEditions = 2
+
+// supportedFeatures returns the CodeGeneratorResponse features the generator implements, declared to protoc so it
+// accepts files using them: proto3 optional fields of supported proto3 files track their presence like proto2 fields,
+// and editions files are supported when the editions syntax is, with the features of the supported editions resolved
+func supportedFeatures() uint64 {
+	var features uint64
+	if isSupportedSyntax("proto3") {
+		features |= uint64(pluginpb.CodeGeneratorResponse_FEATURE_PROTO3_OPTIONAL)
+	}
+	if isSupportedSyntax("editions") && len(supportedEditions) > 0 {
+		features |= featureSupportsEditions
+	}
+	return features
+}
+
 // Generate generates Solidity code from the requested .proto files.
 func (g *Generator) Generate() (*pluginpb.CodeGeneratorResponse, error) {
-	response := &pluginpb.CodeGeneratorResponse{}
+	response := &pluginpb.CodeGeneratorResponse{
+		SupportedFeatures: proto.Uint64(supportedFeatures()),
+	}
+	if response.GetSupportedFeatures()&featureSupportsEditions != 0 {
+		setSupportedEditions(response)
+	}
 
 	protoFiles := g.request.GetProtoFile()
 	fileToGenerateSet := make(map[string]struct{})
@@ -241,10 +488,10 @@ func (g *Generator) Generate() (*pluginpb.CodeGeneratorResponse, error) {
 	// Generate shared Google protobuf library if any file uses Google types
 	if usesGoogleTypes {
 		sharedGen := NewSharedGoogleProtobufGenerator("")
-		if err := sharedGen.GenerateSharedGoogleProtobuf(g.protobufLibImportPath); err != nil {
+		if err := sharedGen.GenerateSharedGoogleProtobuf(g.protobufLibImportPath, g.solidityVersion); err != nil {
 			return nil, fmt.Errorf("failed to generate shared Google protobuf library: %w", err)
 		}
-		
+
 		// Add the shared library file to the response
 		sharedFilePath := "google/protobuf/google_protobuf.sol"
 		sharedContent := sharedGen.GetGeneratedContent()
@@ -252,7 +499,7 @@ func (g *Generator) Generate() (*pluginpb.CodeGeneratorResponse, error) {
 			Name:    &sharedFilePath,
 			Content: &sharedContent,
 		})
-		
+
 		// Mark that Google protobuf types have been generated globally
 		g.googleProtobufGenerated = true
 	}
@@ -284,6 +531,15 @@ func (g *Generator) Generate() (*pluginpb.CodeGeneratorResponse, error) {
 		if responseFile != nil {
 			log.Printf("DEBUG: Successfully generated file for %s", protoFile.GetName())
 			response.File = append(response.File, responseFile)
+
+			// Type definitions for signers, next to the generated Solidity file
+			if g.eip712 {
+				typesFile, err := NewEIP712Generator(g).GenerateTypesFile(protoFile, responseFile.GetName())
+				if err != nil {
+					return nil, err
+				}
+				response.File = append(response.File, typesFile)
+			}
 		} else {
 			log.Printf("DEBUG: Skipped file %s (no output generated)", protoFile.GetName())
 		}
@@ -300,22 +556,124 @@ func (g *Generator) Generate() (*pluginpb.CodeGeneratorResponse, error) {
 	return response, nil
 }
 
+// supportsMcopy checks if the generated code may use mcopy, which needs both the compiler and the EVM version to
+// provide it. The EVM version must be given explicitly, as the compiler default may be newer than the target chain.
+func (g *Generator) supportsMcopy() bool {
+	return g.solidityVersion.supportsMcopy() && g.evmVersion.atLeast("cancun")
+}
+
+// effectiveParameters returns all plugin parameters with their effective values, in a fixed order
+func (g *Generator) effectiveParameters() string {
+	parameters := []string{
+		"license=" + g.licenseString,
+		"compile=" + fromCompileFlag(g.compileFlag),
+		"generate=" + fromGenerateFlag(g.generateFlag),
+		"unknown_fields=" + fromUnknownFieldsFlag(g.unknownFieldsFlag),
+		"optimize=" + fromOptimizeFlag(g.optimizeFlag),
+		"hash_function=" + fromHashFunctionFlag(g.hashFunctionFlag),
+		"protobuf_lib_import=" + g.protobufLibImportPath,
+		"strict_field_numbers=" + strconv.FormatBool(g.strictFieldNumberValidation),
+		"strict_enum_validation=" + strconv.FormatBool(g.strictEnumValidation),
+		"allow_empty_packed_arrays=" + strconv.FormatBool(g.allowEmptyPackedArrays),
+		"strict_canonical=" + strconv.FormatBool(g.strictCanonical),
+		"max_depth=" + strconv.Itoa(g.maxDepth),
+		"allow_non_monotonic_fields=" + strconv.FormatBool(g.allowNonMonotonicFields),
+		"build_metadata=" + strconv.FormatBool(g.buildMetadata),
+		"solidity_version=" + g.solidityVersion.specifier,
+		"evm_version=" + g.evmVersion.name,
+		"storage_helpers=" + strconv.FormatBool(g.storageHelpers),
+		"eip712=" + strconv.FormatBool(g.eip712),
+		"events=" + strconv.FormatBool(g.events),
+		"validate=" + strconv.FormatBool(g.validate),
+		"validate_on_decode=" + strconv.FormatBool(g.validateOnDecode),
+	}
+	return strings.Join(parameters, ",")
+}
+
 // buildGlobalMessageRegistry builds a registry of all messages for type resolution
 func (g *Generator) buildGlobalMessageRegistry(protoFiles []*descriptorpb.FileDescriptorProto) {
 	if g.messageRegistry == nil {
 		g.messageRegistry = make(map[string]*descriptorpb.DescriptorProto)
 	}
+	if g.messageStructNames == nil {
+		g.messageStructNames = make(map[string]string)
+	}
 	for _, protoFile := range protoFiles {
-		pkg := protoFile.GetPackage()
 		for _, msg := range protoFile.GetMessageType() {
-			// Use fully qualified name for global registry
-			fullName := msg.GetName()
-			if len(pkg) > 0 {
-				fullName = pkg + "." + fullName
+			g.registerMessage(protoFile.GetPackage(), "", msg)
+		}
+	}
+
+	// Find the message fields that would make structs contain themselves
+	typeGraph := newTypeGraph(protoFiles)
+	g.recursiveFields = typeGraph.recursiveFields()
+	g.abiRecursiveMessages = typeGraph.abiRecursiveMessages(g.recursiveFields)
+
+	// Resolve the presence, packing and encoding of fields from the syntax or edition features of their files
+	g.resolveFeatures(protoFiles)
+}
+
+// registerMessage adds a message and its nested messages to the registry under their fully qualified names
+func (g *Generator) registerMessage(scope string, outerStructName string, msg *descriptorpb.DescriptorProto) {
+	fullName := msg.GetName()
+	if len(scope) > 0 {
+		fullName = scope + "." + fullName
+	}
+	structName := sanitizeKeyword(msg.GetName())
+	if len(outerStructName) > 0 {
+		structName = outerStructName + "_" + msg.GetName()
+	}
+
+	g.messageRegistry[fullName] = msg
+	g.messageStructNames[fullName] = structName
+	for _, nested := range msg.GetNestedType() {
+		g.registerMessage(fullName, structName, nested)
+	}
+}
+
+// isRecursiveField checks if a field refers back to its own message and is held as serialized bytes
+func (g *Generator) isRecursiveField(field *descriptorpb.FieldDescriptorProto) bool {
+	return g.recursiveFields[field]
+}
+
+// isABIEncodable checks if the struct of a message can be ABI encoded, which rules out structs reaching a cycle
+func (g *Generator) isABIEncodable(descriptor *descriptorpb.DescriptorProto) bool {
+	for _, field := range descriptor.GetField() {
+		if isEmbeddedMessageField(field) && !g.isRecursiveField(field) && g.abiRecursiveMessages[strings.TrimPrefix(field.GetTypeName(), ".")] {
+			return false
+		}
+	}
+	return true
+}
+
+// hasFieldPresence checks if a field tracks whether it was set, in a _has_ member of its struct
+func (g *Generator) hasFieldPresence(field *descriptorpb.FieldDescriptorProto) bool {
+	return g.presenceFields[field]
+}
+
+// isFieldPacked checks if a field is packed, by its packed option or, in editions files, by its features
+func (g *Generator) isFieldPacked(field *descriptorpb.FieldDescriptorProto) bool {
+	return field.GetOptions().GetPacked() || g.packedFields[field]
+}
+
+// hasRequiredFields checks if any of the given messages, or the messages nested in them, has a required field
+func (g *Generator) hasRequiredFields(messages []*descriptorpb.DescriptorProto) bool {
+	for _, msg := range messages {
+		for _, field := range msg.GetField() {
+			if g.requiredFields[field] {
+				return true
 			}
-			g.messageRegistry[fullName] = msg
+		}
+		if g.hasRequiredFields(msg.GetNestedType()) {
+			return true
 		}
 	}
+	return false
+}
+
+// isFieldExpanded checks if a field is a repeated numeric field of a proto2 file encoded with one key per element
+func (g *Generator) isFieldExpanded(field *descriptorpb.FieldDescriptorProto) bool {
+	return g.expandedFields[field]
 }
 
 // generateFile generates Solidity code from a single .proto file.
@@ -328,17 +686,24 @@ func (g *Generator) generateFile(protoFile *descriptorpb.FileDescriptorProto) (*
 		// and may use proto2 syntax or have complex nested structures
 		return nil, nil
 	}
-
-	// Only support proto3
-	syntax := protoFile.GetSyntax()
-	if len(syntax) == 0 {
-		return nil, fmt.Errorf("file %s has no syntax declaration", fileName)
+	if IsSolidityOptionsDependency(fileName) || IsValidationRulesDependency(fileName) {
+		// Custom options only annotate fields, there is nothing to generate
+		return nil, nil
 	}
 
-	err := checkSyntaxVersion(syntax)
+	// Support proto2, proto3 and editions, files without a syntax declaration are proto2
+	err := checkSyntaxVersion(fileSyntax(protoFile))
 	if err != nil {
 		return nil, err
 	}
+	if fileSyntax(protoFile) == "editions" {
+		if err := checkEdition(protoFile); err != nil {
+			return nil, err
+		}
+		if err := checkEnumTypes(protoFile); err != nil {
+			return nil, err
+		}
+	}
 
 	// Validate field numbers in all messages if strict validation is enabled
 	if g.strictFieldNumberValidation {
@@ -349,9 +714,19 @@ func (g *Generator) generateFile(protoFile *descriptorpb.FileDescriptorProto) (*
 		}
 	}
 
-	// Validate repeated numeric fields are packed
+	// Validate repeated numeric fields are packed, or expanded in proto2 files
+	isEncodable := func(field *descriptorpb.FieldDescriptorProto) bool {
+		return g.isFieldPacked(field) || g.isFieldExpanded(field)
+	}
+	for _, descriptor := range protoFile.GetMessageType() {
+		if err := checkRepeatedNumericFields(descriptor.GetField(), isEncodable); err != nil {
+			return nil, fmt.Errorf("invalid field in message '%s': %v", descriptor.GetName(), err)
+		}
+	}
+
+	// Validate there are no proto2 groups or delimited message fields
 	for _, descriptor := range protoFile.GetMessageType() {
-		if err := checkRepeatedNumericFields(descriptor.GetField()); err != nil {
+		if err := checkGroupFields(descriptor, g.delimitedFields); err != nil {
 			return nil, fmt.Errorf("invalid field in message '%s': %v", descriptor.GetName(), err)
 		}
 	}
@@ -359,8 +734,17 @@ func (g *Generator) generateFile(protoFile *descriptorpb.FileDescriptorProto) (*
 	// Create a new buffer for the file
 	b := NewWriteableBuffer()
 
+	// Compute build metadata if requested
+	var buildMetadata *BuildMetadata
+	if g.buildMetadata {
+		buildMetadata, err = NewBuildMetadata(protoFile, g.effectiveParameters())
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// Initialize components
-	fileHeaderGen := NewFileHeaderGenerator(g.versionString, g.licenseString)
+	fileHeaderGen := NewFileHeaderGenerator(g.versionString, g.licenseString, g.solidityVersion, buildMetadata)
 	importManager := NewImportManager(g.protobufLibImportPath)
 	libraryGen := NewLibraryGenerator(g.generateFlag)
 	fileNaming := NewFileNaming()
@@ -391,6 +775,7 @@ func (g *Generator) generateFile(protoFile *descriptorpb.FileDescriptorProto) (*
 
 	// Generate main library structure
 	libraryGen.GenerateMainLibrary(packageName, b)
+	fileHeaderGen.GenerateSchemaHash(b)
 
 	// Generate enums
 	err = libraryGen.GenerateEnums(protoFile, g, b)
@@ -404,12 +789,55 @@ func (g *Generator) generateFile(protoFile *descriptorpb.FileDescriptorProto) (*
 		return nil, err
 	}
 
+	// Generate decoder errors
+	if g.solidityVersion.supportsCustomErrors() && (g.generateFlag == generateFlagAll || g.generateFlag == generateFlagDecoder) {
+		b.P("// Raised when nested messages exceed the maximum decoding depth")
+		b.P("error MaxDepthExceeded(uint64 depth);")
+		b.P0()
+	}
+	if g.solidityVersion.supportsCustomErrors() && (g.generateFlag == generateFlagAll || g.generateFlag == generateFlagDecoder) {
+		b.P("// Raised when a buffer converted by to_abi is not exactly one valid encoded message")
+		b.P("error InvalidEncoding();")
+		b.P0()
+	}
+	if g.solidityVersion.supportsCustomErrors() && (g.generateFlag == generateFlagAll || g.generateFlag == generateFlagEncoder) && g.hasRequiredFields(protoFile.GetMessageType()) {
+		b.P("// Raised when encoding a message whose required field is not set")
+		b.P("error MissingRequiredField(uint64 field_number);")
+		b.P0()
+	}
+
 	// Generate float/double helpers
 	err = g.generateFloatDoubleHelpers(b)
 	if err != nil {
 		return nil, err
 	}
 
+	// Generate canonical encoding helpers used by the codec validators and strict decoders
+	NewCanonicalGenerator(g).GenerateCanonicalHelpers(b)
+
+	// Generate memory helpers used by the field decoders of codec libraries
+	NewCodecHelperGenerator(g).GenerateSliceHelpers(b)
+
+	// Generate size helpers used by the encoders of codec libraries to size their buffer
+	if g.generateFlag == generateFlagAll || g.generateFlag == generateFlagEncoder {
+		NewSizeGenerator(g).GenerateSizeHelpers(b)
+	}
+
+	// Generate helpers used by validation functions
+	if g.validate {
+		NewValidateGenerator(g).GenerateValidationHelpers(b)
+	}
+
+	// Generate decimal integer conversions used by codecs of string fields set with (sol.uint256) or (sol.int256)
+	if fileHasDecimalIntegerFields(protoFile) {
+		NewDecimalGenerator(g).GenerateDecimalHelpers(b)
+	}
+
+	// Generate inline assembly helpers used by gas-optimized decoders
+	if g.optimizeFlag == optimizeFlagGas && (g.generateFlag == generateFlagAll || g.generateFlag == generateFlagDecoder) {
+		NewFastPathGenerator(g).GenerateFastPathHelpers(b)
+	}
+
 	// Close main library
 	libraryGen.CloseMainLibrary(b)
 
@@ -0,0 +1,168 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+// proto3OptionalFile returns a proto3 file with a proto3 optional field, in its synthetic oneof as protoc passes it
+func proto3OptionalFile() *descriptorpb.FileDescriptorProto {
+	return &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("optional.proto"),
+		Package: proto.String("optional"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("Item"),
+			Field: []*descriptorpb.FieldDescriptorProto{{
+				Name:           proto.String("count"),
+				JsonName:       proto.String("count"),
+				Number:         proto.Int32(1),
+				Label:          descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+				Type:           descriptorpb.FieldDescriptorProto_TYPE_UINT32.Enum(),
+				OneofIndex:     proto.Int32(0),
+				Proto3Optional: proto.Bool(true),
+			}},
+			OneofDecl: []*descriptorpb.OneofDescriptorProto{{
+				Name: proto.String("_count"),
+			}},
+		}},
+	}
+}
+
+// editionsFile returns an editions file of the given edition, held among unknown fields by this protobuf version
+func editionsFile(edition int32) *descriptorpb.FileDescriptorProto {
+	file := &descriptorpb.FileDescriptorProto{
+		Name:    proto.String(fmt.Sprintf("editions_%d.proto", edition)),
+		Package: proto.String(fmt.Sprintf("editions_%d", edition)),
+		Syntax:  proto.String("editions"),
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("Entry"),
+			Field: []*descriptorpb.FieldDescriptorProto{{
+				Name:     proto.String("id"),
+				JsonName: proto.String("id"),
+				Number:   proto.Int32(1),
+				Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+				Type:     descriptorpb.FieldDescriptorProto_TYPE_UINT32.Enum(),
+			}},
+		}},
+	}
+	unknown := protowire.AppendTag(nil, fileEditionNumber, protowire.VarintType)
+	unknown = protowire.AppendVarint(unknown, uint64(edition))
+	file.ProtoReflect().SetUnknown(unknown)
+	return file
+}
+
+// generate runs the generator on the given files, failing the test on any error
+func generate(t *testing.T, files ...*descriptorpb.FileDescriptorProto) *pluginpb.CodeGeneratorResponse {
+	t.Helper()
+	return generateWithParameter(t, "", files...)
+}
+
+// generateWithParameter runs the generator with the given plugin parameter, failing the test on any error
+func generateWithParameter(t *testing.T, parameter string, files ...*descriptorpb.FileDescriptorProto) *pluginpb.CodeGeneratorResponse {
+	t.Helper()
+	request := &pluginpb.CodeGeneratorRequest{Parameter: proto.String(parameter), ProtoFile: files}
+	for _, file := range files {
+		request.FileToGenerate = append(request.FileToGenerate, file.GetName())
+	}
+
+	g := New(request, "test")
+	if err := g.ParseParameters(); err != nil {
+		t.Fatalf("ParseParameters: %v", err)
+	}
+	response, err := g.Generate()
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if response.GetError() != "" {
+		t.Fatalf("Generate: response error %s", response.GetError())
+	}
+	return response
+}
+
+// responseEdition returns a varint member of the response that this protobuf version does not define
+func responseEdition(t *testing.T, response *pluginpb.CodeGeneratorResponse, number protowire.Number) int32 {
+	t.Helper()
+	wireType, value, found := unknownFieldValue(response.ProtoReflect().GetUnknown(), number)
+	if !found || wireType != protowire.VarintType {
+		t.Fatalf("response field %d not set", number)
+	}
+	edition, n := protowire.ConsumeVarint(value)
+	if n < 0 {
+		t.Fatalf("response field %d malformed", number)
+	}
+	return int32(edition)
+}
+
+func TestGenerateDeclaresSupportedFeatures(t *testing.T) {
+	files := []*descriptorpb.FileDescriptorProto{proto3OptionalFile()}
+	for _, edition := range supportedEditions {
+		files = append(files, editionsFile(edition))
+	}
+	response := generate(t, files...)
+
+	features := response.GetSupportedFeatures()
+	if features&uint64(pluginpb.CodeGeneratorResponse_FEATURE_PROTO3_OPTIONAL) == 0 {
+		t.Errorf("FEATURE_PROTO3_OPTIONAL not declared, features %d", features)
+	}
+	if features&featureSupportsEditions == 0 {
+		t.Errorf("FEATURE_SUPPORTS_EDITIONS not declared, features %d", features)
+	}
+	if minimum := responseEdition(t, response, responseMinimumEditionNumber); minimum != editionProto2 {
+		t.Errorf("minimum_edition = %d, want %d", minimum, editionProto2)
+	}
+	if maximum := responseEdition(t, response, responseMaximumEditionNumber); maximum != edition2024 {
+		t.Errorf("maximum_edition = %d, want %d", maximum, edition2024)
+	}
+
+	// The declared features are backed by generated code for files using them
+	var optionalContent string
+	editionsGenerated := 0
+	for _, file := range response.GetFile() {
+		switch {
+		case strings.Contains(file.GetName(), "optional"):
+			optionalContent = file.GetContent()
+		case strings.Contains(file.GetName(), "editions_"):
+			editionsGenerated++
+		}
+	}
+	if !strings.Contains(optionalContent, "bool _has_count;") {
+		t.Errorf("proto3 optional field does not track its presence")
+	}
+	if editionsGenerated != len(supportedEditions) {
+		t.Errorf("generated %d editions files, want %d", editionsGenerated, len(supportedEditions))
+	}
+}
+
+func TestGenerateRejectsUnsupportedEdition(t *testing.T) {
+	request := &pluginpb.CodeGeneratorRequest{
+		FileToGenerate: []string{"editions_1002.proto"},
+		ProtoFile:      []*descriptorpb.FileDescriptorProto{editionsFile(1002)},
+	}
+	_, err := New(request, "test").Generate()
+	if err == nil || !strings.Contains(err.Error(), "unsupported edition 1002") {
+		t.Errorf("Generate: got error %v, want unsupported edition", err)
+	}
+}
+
+func TestParseParametersRejectsMissingValue(t *testing.T) {
+	request := &pluginpb.CodeGeneratorRequest{Parameter: proto.String("generate=all,license")}
+	err := New(request, "test").ParseParameters()
+	if err == nil || !strings.Contains(err.Error(), "license must be given as key=value") {
+		t.Errorf("ParseParameters: got error %v, want key=value error", err)
+	}
+}
+
+func TestParseParametersRejectsUnboundedMaxDepth(t *testing.T) {
+	request := &pluginpb.CodeGeneratorRequest{Parameter: proto.String("max_depth=0")}
+	err := New(request, "test").ParseParameters()
+	if err == nil || !strings.Contains(err.Error(), "max_depth must be a positive integer") {
+		t.Errorf("ParseParameters: got error %v, want positive max_depth error", err)
+	}
+}
@@ -45,7 +45,7 @@ func (im *ImportManager) GenerateImports(protoFile *descriptorpb.FileDescriptorP
 
 	// Generate imports for dependencies
 	for _, dependency := range protoFile.GetDependency() {
-		if IsGoogleDependency(dependency) {
+		if IsGoogleDependency(dependency) || IsSolidityOptionsDependency(dependency) || IsValidationRulesDependency(dependency) {
 			continue
 		}
 		importPath := im.dependencyToImportPath(dependency, generatedFileName)
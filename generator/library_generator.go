@@ -2,6 +2,7 @@ package generator
 
 import (
 	"fmt"
+	"sort"
 
 	"google.golang.org/protobuf/types/descriptorpb"
 )
@@ -77,7 +78,7 @@ func (lg *LibraryGenerator) GenerateMessageStructs(protoFile *descriptorpb.FileD
 	if g.helperMessages[packageName] != nil {
 		b.P("// Helper messages for PostFiat enhancements")
 		b.P0()
-		for _, helperMessage := range g.helperMessages[packageName] {
+		for _, helperMessage := range sortedHelperMessages(g.helperMessages[packageName]) {
 			err := g.generateMessageStruct(helperMessage, packageName, b)
 			if err != nil {
 				return err
@@ -108,7 +109,7 @@ func (lg *LibraryGenerator) GenerateCodecLibraries(protoFile *descriptorpb.FileD
 	// Generate helper message codec libraries OUTSIDE the main library block
 	// Only generate codecs for helper messages that have successfully generated structs
 	if g.helperMessages[packageName] != nil {
-		for _, helperMessage := range g.helperMessages[packageName] {
+		for _, helperMessage := range sortedHelperMessages(g.helperMessages[packageName]) {
 			if g.successfullyGeneratedStructs[helperMessage.GetName()] {
 				err := g.generateMessageCodec(helperMessage, packageName, b)
 				if err != nil {
@@ -121,6 +122,21 @@ func (lg *LibraryGenerator) GenerateCodecLibraries(protoFile *descriptorpb.FileD
 	return nil
 }
 
+// sortedHelperMessages returns helper messages ordered by name, so output is stable across runs
+func sortedHelperMessages(helperMessages map[string]*descriptorpb.DescriptorProto) []*descriptorpb.DescriptorProto {
+	names := make([]string, 0, len(helperMessages))
+	for name := range helperMessages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	sorted := make([]*descriptorpb.DescriptorProto, 0, len(names))
+	for _, name := range names {
+		sorted = append(sorted, helperMessages[name])
+	}
+	return sorted
+}
+
 // packageToLibraryName converts a protobuf package name to a valid Solidity library name
 func (lg *LibraryGenerator) packageToLibraryName(packageName string) string {
 	return PackageToLibraryName(packageName)
@@ -255,7 +255,12 @@ func (g *Generator) generateMessage(descriptor *descriptorpb.DescriptorProto, pa
 					if err != nil {
 						return err
 					}
-					b.P(fmt.Sprintf("%s%s %s;", typeName, arrayStr, fieldName))
+					if g.isRecursiveField(field) {
+						// Solidity structs cannot contain themselves, so keep the encoded message instead
+						b.P(fmt.Sprintf("bytes %s; // Encoded %s", fieldName, typeName))
+					} else {
+						b.P(fmt.Sprintf("%s%s %s;", typeName, arrayStr, fieldName))
+					}
 				}
 			case descriptorpb.FieldDescriptorProto_TYPE_STRING:
 				// PostFiat enhancement: Use wrapper message for repeated strings
@@ -271,7 +276,7 @@ func (g *Generator) generateMessage(descriptor *descriptorpb.DescriptorProto, pa
 					b.P(fmt.Sprintf("%s%s %s;", wrapperName, arrayStr, fieldName))
 				} else {
 					// Regular string field
-					fieldType, err := typeToSol(fieldDescriptorType)
+					fieldType, err := fieldToSol(field)
 					if err != nil {
 						return errors.New(err.Error() + ": " + structName + "." + fieldName)
 					}
@@ -291,7 +296,7 @@ func (g *Generator) generateMessage(descriptor *descriptorpb.DescriptorProto, pa
 					b.P(fmt.Sprintf("%s%s %s;", wrapperName, arrayStr, fieldName))
 				} else {
 					// Regular bytes field
-					fieldType, err := typeToSol(fieldDescriptorType)
+					fieldType, err := fieldToSol(field)
 					if err != nil {
 						return errors.New(err.Error() + ": " + structName + "." + fieldName)
 					}
@@ -299,7 +304,7 @@ func (g *Generator) generateMessage(descriptor *descriptorpb.DescriptorProto, pa
 				}
 			default:
 				// Convert protobuf field type to Solidity native type
-				fieldType, err := typeToSol(fieldDescriptorType)
+				fieldType, err := fieldToSol(field)
 				if err != nil {
 					return errors.New(err.Error() + ": " + structName + "." + fieldName)
 				}
@@ -451,12 +456,17 @@ func (g *Generator) generateMessageStruct(descriptor *descriptorpb.DescriptorPro
 					if err != nil {
 						return err
 					}
-					b.P(fmt.Sprintf("%s%s %s;", typeName, arrayStr, fieldName))
+					if g.isRecursiveField(field) {
+						// Solidity structs cannot contain themselves, so keep the encoded message instead
+						b.P(fmt.Sprintf("bytes %s; // Encoded %s", fieldName, typeName))
+					} else {
+						b.P(fmt.Sprintf("%s%s %s;", typeName, arrayStr, fieldName))
+					}
 				}
 
 			default:
 				// Convert protobuf field type to Solidity native type
-				fieldType, err := typeToSol(fieldDescriptorType)
+				fieldType, err := fieldToSol(field)
 				if err != nil {
 					return errors.New(err.Error() + ": " + structName + "." + fieldName)
 				}
@@ -464,6 +474,14 @@ func (g *Generator) generateMessageStruct(descriptor *descriptorpb.DescriptorPro
 				b.P(fmt.Sprintf("%s%s %s;", fieldType, arrayStr, fieldName))
 			}
 		}
+
+		// Whether each field with presence was set
+		NewPresenceGenerator(g).GeneratePresenceMembers(fields, fieldNameMap, b)
+
+		// Raw bytes of unknown fields, written back out by the encoder
+		if g.unknownFieldsFlag == unknownFieldsFlagPreserve {
+			b.P("bytes _unknown;")
+		}
 	}
 
 	b.Unindent()
@@ -489,13 +507,19 @@ func (g *Generator) generateMessageCodec(descriptor *descriptorpb.DescriptorProt
 	b.P(fmt.Sprintf("library %sCodec {", structName))
 	b.Indent()
 
+	// Expose the schema hash of the package library, so each codec can be checked on its own
+	if g.buildMetadata {
+		b.P(fmt.Sprintf("bytes32 internal constant SCHEMA_HASH = %s.SCHEMA_HASH;", PackageToLibraryName(packageName)))
+		b.P0()
+	}
+
 	// Only generate codec functions if we have fields
 	if len(fields) > 0 {
 		// Generate helper functions first
-		codecHelperGen := NewCodecHelperGenerator()
+		codecHelperGen := NewCodecHelperGenerator(g)
 		// Create qualified struct name for codec functions
 		qualifiedStructName := PackageToLibraryName(packageName) + "." + structName
-		err := codecHelperGen.GenerateCodecHelpers(qualifiedStructName, fields, fieldNameMap, b)
+		err := codecHelperGen.GenerateCodecHelpers(qualifiedStructName, descriptor, fieldNameMap, b)
 		if err != nil {
 			return err
 		}
@@ -505,6 +529,18 @@ func (g *Generator) generateMessageCodec(descriptor *descriptorpb.DescriptorProt
 			if err != nil {
 				return err
 			}
+
+			// Generate canonical encoding validator
+			canonicalGen := NewCanonicalGenerator(g)
+			err = canonicalGen.GenerateCanonicalValidator(qualifiedStructName, descriptor, fieldNameMap, b)
+			if err != nil {
+				return err
+			}
+
+			// Structs reaching a cycle of structs cannot be ABI encoded
+			if g.isABIEncodable(descriptor) {
+				NewABIGenerator(g).GenerateToABI(qualifiedStructName, b)
+			}
 		}
 
 		if g.generateFlag == generateFlagAll || g.generateFlag == generateFlagEncoder {
@@ -512,6 +548,44 @@ func (g *Generator) generateMessageCodec(descriptor *descriptorpb.DescriptorProt
 			if err != nil {
 				return err
 			}
+
+			// Structs reaching a cycle of structs cannot be ABI encoded
+			if g.isABIEncodable(descriptor) {
+				NewABIGenerator(g).GenerateFromABI(qualifiedStructName, b)
+			}
+		}
+
+		err = NewEqualsGenerator(g).GenerateEquals(qualifiedStructName, descriptor, fieldNameMap, b)
+		if err != nil {
+			return err
+		}
+
+		if g.storageHelpers {
+			err := NewStorageGenerator(g).GenerateStore(qualifiedStructName, descriptor, fieldNameMap, b)
+			if err != nil {
+				return err
+			}
+		}
+
+		if g.validate {
+			err := NewValidateGenerator(g).GenerateValidate(qualifiedStructName, descriptor, fieldNameMap, b)
+			if err != nil {
+				return err
+			}
+		}
+
+		if g.events {
+			err := NewEventGenerator(g).GenerateEvent(qualifiedStructName, descriptor, fieldNameMap, b)
+			if err != nil {
+				return err
+			}
+		}
+
+		if g.eip712 {
+			err := NewEIP712Generator(g).GenerateHashStruct(qualifiedStructName, descriptor, fieldNameMap, b)
+			if err != nil {
+				return err
+			}
 		}
 	}
 
@@ -0,0 +1,216 @@
+package generator
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// SolidityOptionsFile is the import path of the custom options definition, solidity/options.proto
+const SolidityOptionsFile = "solidity/options.proto"
+
+// Extension numbers of the options of solidity/options.proto, from the range reserved for use within an organization
+const (
+	solOptionIndexed   protowire.Number = 51701
+	solOptionType      protowire.Number = 51702
+	solOptionFixedSize protowire.Number = 51703
+	solOptionUint256   protowire.Number = 51704
+	solOptionInt256    protowire.Number = 51705
+)
+
+// fieldOptionValue returns the wire type and raw value of a custom field option.
+// The plugin has no generated code for the extensions, so they are found among the unknown fields of FieldOptions.
+func fieldOptionValue(field *descriptorpb.FieldDescriptorProto, number protowire.Number) (protowire.Type, []byte, bool) {
+	options := field.GetOptions()
+	if options == nil {
+		return 0, nil, false
+	}
+	return unknownFieldValue(options.ProtoReflect().GetUnknown(), number)
+}
+
+// unknownFieldValue returns the wire type and raw value of a field among the unknown fields of a message.
+// As for any singular field, the last occurrence wins.
+func unknownFieldValue(unknown []byte, number protowire.Number) (protowire.Type, []byte, bool) {
+	var foundType protowire.Type
+	var foundValue []byte
+	found := false
+	for len(unknown) > 0 {
+		fieldNumber, wireType, n := protowire.ConsumeTag(unknown)
+		if n < 0 {
+			return 0, nil, false
+		}
+		unknown = unknown[n:]
+
+		valueLength := protowire.ConsumeFieldValue(fieldNumber, wireType, unknown)
+		if valueLength < 0 {
+			return 0, nil, false
+		}
+		if fieldNumber == number {
+			foundType = wireType
+			foundValue = unknown[:valueLength]
+			found = true
+		}
+		unknown = unknown[valueLength:]
+	}
+	return foundType, foundValue, found
+}
+
+// fieldOptionBool returns the value of a bool custom field option, false if it is not set
+func fieldOptionBool(field *descriptorpb.FieldDescriptorProto, number protowire.Number) bool {
+	wireType, value, found := fieldOptionValue(field, number)
+	if !found || wireType != protowire.VarintType {
+		return false
+	}
+	v, n := protowire.ConsumeVarint(value)
+	return n > 0 && v != 0
+}
+
+// fieldOptionString returns the value of a string custom field option
+func fieldOptionString(field *descriptorpb.FieldDescriptorProto, number protowire.Number) (string, bool) {
+	wireType, value, found := fieldOptionValue(field, number)
+	if !found || wireType != protowire.BytesType {
+		return "", false
+	}
+	v, n := protowire.ConsumeBytes(value)
+	return string(v), n > 0
+}
+
+// fieldOptionUint returns the value of an unsigned integer custom field option
+func fieldOptionUint(field *descriptorpb.FieldDescriptorProto, number protowire.Number) (uint64, bool) {
+	wireType, value, found := fieldOptionValue(field, number)
+	if !found || wireType != protowire.VarintType {
+		return 0, false
+	}
+	v, n := protowire.ConsumeVarint(value)
+	return v, n > 0
+}
+
+// fieldTypeOverride returns the Solidity type set with (sol.type), (sol.fixed_size), (sol.uint256) or (sol.int256),
+// empty if there is none. For members encoded as bytes of a fixed length, fixedSize is that length, otherwise it is 0.
+func fieldTypeOverride(field *descriptorpb.FieldDescriptorProto) (solType string, fixedSize int, err error) {
+	typeOption, hasType := fieldOptionString(field, solOptionType)
+	sizeOption, hasSize := fieldOptionUint(field, solOptionFixedSize)
+	isUint256 := fieldOptionBool(field, solOptionUint256)
+	isInt256 := fieldOptionBool(field, solOptionInt256)
+	if isUint256 || isInt256 {
+		return largeIntegerOverride(field, isUint256, isInt256, hasType || hasSize)
+	}
+	if !hasType && !hasSize {
+		return "", 0, nil
+	}
+	if isFieldRepeated(field) {
+		return "", 0, fmt.Errorf("field %s: sol.type and sol.fixed_size are only supported on singular fields", field.GetName())
+	}
+
+	switch field.GetType() {
+	case descriptorpb.FieldDescriptorProto_TYPE_BYTES:
+		if hasSize && (sizeOption < 1 || sizeOption > 32) {
+			return "", 0, fmt.Errorf("field %s: sol.fixed_size must be between 1 and 32", field.GetName())
+		}
+		if !hasType {
+			return fmt.Sprintf("bytes%d", sizeOption), int(sizeOption), nil
+		}
+
+		switch {
+		case typeOption == "bytes":
+			solType, fixedSize = "bytes", 0
+		case typeOption == "address":
+			solType, fixedSize = "address", 20
+		case strings.HasPrefix(typeOption, "bytes"):
+			size, err := strconv.Atoi(strings.TrimPrefix(typeOption, "bytes"))
+			if err != nil || size < 1 || size > 32 {
+				return "", 0, fmt.Errorf("field %s: unsupported sol.type %s for a bytes field, allowed values are <address, bytes1 to bytes32, bytes>", field.GetName(), typeOption)
+			}
+			solType, fixedSize = typeOption, size
+		default:
+			return "", 0, fmt.Errorf("field %s: unsupported sol.type %s for a bytes field, allowed values are <address, bytes1 to bytes32, bytes>", field.GetName(), typeOption)
+		}
+		if hasSize && int(sizeOption) != fixedSize {
+			return "", 0, fmt.Errorf("field %s: sol.fixed_size %d does not match sol.type %s", field.GetName(), sizeOption, typeOption)
+		}
+		return solType, fixedSize, nil
+	case descriptorpb.FieldDescriptorProto_TYPE_STRING:
+		if hasSize {
+			return "", 0, fmt.Errorf("field %s: sol.fixed_size is only supported on bytes fields", field.GetName())
+		}
+		if typeOption != "string" && typeOption != "bytes" {
+			return "", 0, fmt.Errorf("field %s: unsupported sol.type %s for a string field, allowed values are <string, bytes>", field.GetName(), typeOption)
+		}
+		return typeOption, 0, nil
+	}
+
+	return "", 0, fmt.Errorf("field %s: sol.type and sol.fixed_size are only supported on string and bytes fields", field.GetName())
+}
+
+// largeIntegerOverride returns the Solidity type of a field set with (sol.uint256) or (sol.int256).
+// Bytes fields hold the 32 byte big-endian value, string fields its decimal representation.
+func largeIntegerOverride(field *descriptorpb.FieldDescriptorProto, isUint256 bool, isInt256 bool, hasOtherOverride bool) (string, int, error) {
+	if isUint256 && isInt256 {
+		return "", 0, fmt.Errorf("field %s: sol.uint256 and sol.int256 cannot be combined", field.GetName())
+	}
+	if hasOtherOverride {
+		return "", 0, fmt.Errorf("field %s: sol.uint256 and sol.int256 cannot be combined with sol.type or sol.fixed_size", field.GetName())
+	}
+	if isFieldRepeated(field) {
+		return "", 0, fmt.Errorf("field %s: sol.uint256 and sol.int256 are only supported on singular fields", field.GetName())
+	}
+
+	solType := "uint256"
+	if isInt256 {
+		solType = "int256"
+	}
+	switch field.GetType() {
+	case descriptorpb.FieldDescriptorProto_TYPE_BYTES:
+		return solType, 32, nil
+	case descriptorpb.FieldDescriptorProto_TYPE_STRING:
+		return solType, 0, nil
+	}
+	return "", 0, fmt.Errorf("field %s: sol.uint256 and sol.int256 are only supported on string and bytes fields", field.GetName())
+}
+
+// isLargeIntegerType checks if a Solidity type is one of the integer types set with (sol.uint256) or (sol.int256)
+func isLargeIntegerType(solType string) bool {
+	return solType == "uint256" || solType == "int256"
+}
+
+// isDecimalIntegerField checks if a field is a string holding the decimal representation of a uint256 or int256
+func isDecimalIntegerField(field *descriptorpb.FieldDescriptorProto) bool {
+	if field.GetType() != descriptorpb.FieldDescriptorProto_TYPE_STRING {
+		return false
+	}
+	solType, _, err := fieldTypeOverride(field)
+	return err == nil && isLargeIntegerType(solType)
+}
+
+// fieldToSol converts the type of a singular or packed field to its Solidity type, honoring (sol.type) and (sol.fixed_size)
+func fieldToSol(field *descriptorpb.FieldDescriptorProto) (string, error) {
+	solType, _, err := fieldTypeOverride(field)
+	if err != nil {
+		return "", err
+	}
+	if len(solType) > 0 {
+		return solType, nil
+	}
+	return typeToSol(field.GetType())
+}
+
+// fixedValueFromWord returns the conversion of a 32 byte word holding a fixed size value, left-aligned, to its Solidity type
+func fixedValueFromWord(solType string, fixedSize int, word string) string {
+	switch solType {
+	case "address":
+		return fmt.Sprintf("address(bytes20(%s))", word)
+	case "uint256":
+		return fmt.Sprintf("uint256(%s)", word)
+	case "int256":
+		return fmt.Sprintf("int256(uint256(%s))", word)
+	}
+	return fmt.Sprintf("bytes%d(%s)", fixedSize, word)
+}
+
+// isIndexedField checks if a field is marked with (sol.indexed)
+func isIndexedField(field *descriptorpb.FieldDescriptorProto) bool {
+	return fieldOptionBool(field, solOptionIndexed)
+}
@@ -0,0 +1,73 @@
+package generator
+
+import (
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// bytesFieldWithOptions returns a bytes field whose options hold the given serialized custom options
+func bytesFieldWithOptions(options []byte) *descriptorpb.FieldDescriptorProto {
+	field := &descriptorpb.FieldDescriptorProto{
+		Name:    proto.String("owner"),
+		Number:  proto.Int32(1),
+		Label:   descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+		Type:    descriptorpb.FieldDescriptorProto_TYPE_BYTES.Enum(),
+		Options: &descriptorpb.FieldOptions{},
+	}
+	field.GetOptions().ProtoReflect().SetUnknown(options)
+	return field
+}
+
+func TestFieldToSolReadsTypeOptions(t *testing.T) {
+	// [(sol.type) = "address"]
+	typeOption := protowire.AppendTag(nil, solOptionType, protowire.BytesType)
+	typeOption = protowire.AppendString(typeOption, "address")
+	// [(sol.fixed_size) = 4]
+	sizeOption := protowire.AppendTag(nil, solOptionFixedSize, protowire.VarintType)
+	sizeOption = protowire.AppendVarint(sizeOption, 4)
+	// [(sol.uint256) = true]
+	uint256Option := protowire.AppendTag(nil, solOptionUint256, protowire.VarintType)
+	uint256Option = protowire.AppendVarint(uint256Option, 1)
+
+	tests := []struct {
+		options []byte
+		want    string
+	}{
+		{nil, "bytes"},
+		{typeOption, "address"},
+		{sizeOption, "bytes4"},
+		{uint256Option, "uint256"},
+	}
+	for _, test := range tests {
+		got, err := fieldToSol(bytesFieldWithOptions(test.options))
+		if err != nil {
+			t.Errorf("fieldToSol(%x): %v", test.options, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("fieldToSol(%x) = %s, want %s", test.options, got, test.want)
+		}
+	}
+}
+
+func TestIsIndexedFieldReadsIndexedOption(t *testing.T) {
+	// [(sol.indexed) = true]
+	indexed := protowire.AppendTag(nil, solOptionIndexed, protowire.VarintType)
+	indexed = protowire.AppendVarint(indexed, 1)
+	// [(sol.indexed) = false]
+	notIndexed := protowire.AppendTag(nil, solOptionIndexed, protowire.VarintType)
+	notIndexed = protowire.AppendVarint(notIndexed, 0)
+
+	if !isIndexedField(bytesFieldWithOptions(indexed)) {
+		t.Errorf("field with (sol.indexed) = true is not indexed")
+	}
+	if isIndexedField(bytesFieldWithOptions(notIndexed)) {
+		t.Errorf("field with (sol.indexed) = false is indexed")
+	}
+	if isIndexedField(bytesFieldWithOptions(nil)) {
+		t.Errorf("field without options is indexed")
+	}
+}
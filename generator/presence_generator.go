@@ -0,0 +1,186 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// PresenceGenerator handles generation of field presence, default values and required fields of proto2 and editions messages
+type PresenceGenerator struct {
+	g *Generator
+}
+
+// NewPresenceGenerator creates a new presence generator
+func NewPresenceGenerator(g *Generator) *PresenceGenerator {
+	return &PresenceGenerator{
+		g: g,
+	}
+}
+
+// presenceMemberName returns the name of the struct member recording whether a field was set
+func presenceMemberName(fieldName string) string {
+	return "_has_" + fieldName
+}
+
+// GeneratePresenceMembers generates the struct members recording which fields were set
+func (pg *PresenceGenerator) GeneratePresenceMembers(fields []*descriptorpb.FieldDescriptorProto, fieldNameMap map[int32]string, b *WriteableBuffer) {
+	for _, field := range fields {
+		if pg.g.hasFieldPresence(field) {
+			b.P(fmt.Sprintf("bool %s;", presenceMemberName(fieldNameMap[field.GetNumber()])))
+		}
+	}
+}
+
+// GenerateDefaultValues generates the assignment of [default = ...] values, which fields missing from the encoding keep
+func (pg *PresenceGenerator) GenerateDefaultValues(fields []*descriptorpb.FieldDescriptorProto, fieldNameMap map[int32]string, b *WriteableBuffer) error {
+	var assignments []string
+	for _, field := range fields {
+		if field.DefaultValue == nil {
+			continue
+		}
+		fieldName := fieldNameMap[field.GetNumber()]
+		value, err := pg.defaultValueExpression(field, fieldName)
+		if err != nil {
+			return err
+		}
+		assignments = append(assignments, fmt.Sprintf("instance.%s = %s;", fieldName, value))
+	}
+	if len(assignments) == 0 {
+		return nil
+	}
+
+	b.P("// Default values of fields missing from the encoding")
+	for _, assignment := range assignments {
+		b.P(assignment)
+	}
+	b.P("")
+	return nil
+}
+
+// defaultValueExpression returns the Solidity expression of the default value of a field.
+// Floating point values have no exact fixed point equivalent and overridden types have no text form, so they have no defaults.
+func (pg *PresenceGenerator) defaultValueExpression(field *descriptorpb.FieldDescriptorProto, fieldName string) (string, error) {
+	value := field.GetDefaultValue()
+
+	switch field.GetType() {
+	case descriptorpb.FieldDescriptorProto_TYPE_ENUM:
+		typeName, err := pg.g.getSolTypeName(field)
+		if err != nil {
+			return "", err
+		}
+		return typeName + "." + value, nil
+	case descriptorpb.FieldDescriptorProto_TYPE_STRING,
+		descriptorpb.FieldDescriptorProto_TYPE_BYTES:
+		solType, _, err := fieldTypeOverride(field)
+		if err != nil {
+			return "", err
+		}
+		if len(solType) > 0 {
+			return "", fmt.Errorf("field %s has a default value, which is not supported together with a Solidity type option", fieldName)
+		}
+		if field.GetType() == descriptorpb.FieldDescriptorProto_TYPE_STRING {
+			return solidityBytesLiteral([]byte(value)), nil
+		}
+		// Default values of bytes fields are C escaped
+		decoded, err := unescapeDefaultBytes(value)
+		if err != nil {
+			return "", fmt.Errorf("invalid default value of field %s: %v", fieldName, err)
+		}
+		return solidityBytesLiteral(decoded), nil
+	case descriptorpb.FieldDescriptorProto_TYPE_FLOAT,
+		descriptorpb.FieldDescriptorProto_TYPE_DOUBLE:
+		return "", fmt.Errorf("field %s has a default value, which is not supported for float and double fields", fieldName)
+	case descriptorpb.FieldDescriptorProto_TYPE_MESSAGE:
+		return "", fmt.Errorf("field %s is a message, which cannot have a default value", fieldName)
+	}
+
+	// Booleans and integers are written the same way in both languages
+	return value, nil
+}
+
+// unescapeDefaultBytes decodes a default value escaped the way protoc escapes bytes, with C escape sequences
+func unescapeDefaultBytes(value string) ([]byte, error) {
+	var decoded []byte
+	for i := 0; i < len(value); i++ {
+		if value[i] != '\\' {
+			decoded = append(decoded, value[i])
+			continue
+		}
+		i++
+		if i == len(value) {
+			return nil, errors.New("trailing backslash")
+		}
+
+		switch c := value[i]; {
+		case c == 'n':
+			decoded = append(decoded, '\n')
+		case c == 'r':
+			decoded = append(decoded, '\r')
+		case c == 't':
+			decoded = append(decoded, '\t')
+		case c == '"' || c == '\'' || c == '\\' || c == '?':
+			decoded = append(decoded, c)
+		case c >= '0' && c <= '7':
+			// One to three octal digits
+			end := i + 1
+			for end < len(value) && end < i+3 && value[end] >= '0' && value[end] <= '7' {
+				end++
+			}
+			octal, err := strconv.ParseUint(value[i:end], 8, 8)
+			if err != nil {
+				return nil, fmt.Errorf("invalid octal escape \\%s", value[i:end])
+			}
+			decoded = append(decoded, byte(octal))
+			i = end - 1
+		case c == 'x':
+			// One or two hexadecimal digits
+			end := i + 1
+			for end < len(value) && end < i+3 && strings.IndexByte("0123456789abcdefABCDEF", value[end]) >= 0 {
+				end++
+			}
+			hex, err := strconv.ParseUint(value[i+1:end], 16, 8)
+			if err != nil {
+				return nil, errors.New("hexadecimal escape without digits")
+			}
+			decoded = append(decoded, byte(hex))
+			i = end - 1
+		default:
+			return nil, fmt.Errorf("unknown escape sequence \\%c", c)
+		}
+	}
+	return decoded, nil
+}
+
+// GenerateRequiredFieldsCheck generates the rejection of decoded messages missing one of their required fields
+func (pg *PresenceGenerator) GenerateRequiredFieldsCheck(fields []*descriptorpb.FieldDescriptorProto, fieldNameMap map[int32]string, b *WriteableBuffer) {
+	var conditions []string
+	for _, field := range fields {
+		if pg.g.requiredFields[field] {
+			conditions = append(conditions, "!instance."+presenceMemberName(fieldNameMap[field.GetNumber()]))
+		}
+	}
+	if len(conditions) == 0 {
+		return
+	}
+
+	b.P("// Required fields must be present")
+	b.P(fmt.Sprintf("if (%s) {", strings.Join(conditions, " || ")))
+	b.Indent()
+	b.P("return (false, pos, instance);")
+	b.Unindent()
+	b.P("}")
+	b.P("")
+}
+
+// encodeCondition returns the condition under which the encoder writes a singular field.
+// Fields tracking their presence are written when set, even to their default value, and others when not default.
+func (pg *PresenceGenerator) encodeCondition(field *descriptorpb.FieldDescriptorProto, fieldName string, nonDefault string) string {
+	if pg.g.hasFieldPresence(field) {
+		return "instance." + presenceMemberName(fieldName)
+	}
+	return nonDefault
+}
@@ -18,14 +18,14 @@ func NewSharedGoogleProtobufGenerator(outputDir string) *SharedGoogleProtobufGen
 }
 
 // GenerateSharedGoogleProtobuf generates a shared Google protobuf library file
-func (sgpg *SharedGoogleProtobufGenerator) GenerateSharedGoogleProtobuf(protobufLibImportPath string) error {
+func (sgpg *SharedGoogleProtobufGenerator) GenerateSharedGoogleProtobuf(protobufLibImportPath string, solidityVersion solidityVersion) error {
 	// Generate the shared library content
 	b := NewWriteableBuffer()
 
 	// Generate file header
 	b.P("// File automatically generated by protoc-gen-sol v0.3.0")
 	b.P("// SPDX-License-Identifier: CC0")
-	b.P("pragma solidity ^0.8.19;")
+	solidityVersion.generatePragmas(b)
 	b.P0()
 
 	b.P(fmt.Sprintf("import \"%s\";", protobufLibImportPath))
@@ -0,0 +1,234 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// SizeGenerator handles generation of encoded_size functions, which compute the exact length of the encoding
+// of a message so encoders can allocate their buffer up front. Each size mirrors what the matching field encoder writes.
+type SizeGenerator struct {
+	g *Generator
+}
+
+// NewSizeGenerator creates a new size generator
+func NewSizeGenerator(g *Generator) *SizeGenerator {
+	return &SizeGenerator{
+		g: g,
+	}
+}
+
+// GenerateSizeHelpers generates the size helpers of the main library, used by the encoded_size functions of codec libraries
+func (sg *SizeGenerator) GenerateSizeHelpers(b *WriteableBuffer) {
+	b.P("// Number of bytes of the varint encoding of value")
+	b.P("function varint_size(uint64 value) internal pure returns (uint64) {")
+	b.Indent()
+	b.P("uint64 size = 1;")
+	b.P("while (value >= 0x80) {")
+	b.Indent()
+	b.P("value >>= 7;")
+	b.P("size++;")
+	b.Unindent()
+	b.P("}")
+	b.P("return size;")
+	b.Unindent()
+	b.P("}")
+	b.P0()
+
+	b.P("// Number of bytes of the zigzag varint encoding of value")
+	b.P("function zigzag_size(int64 value) internal pure returns (uint64) {")
+	b.Indent()
+	b.P("return varint_size(uint64((value << 1) ^ (value >> 63)));")
+	b.Unindent()
+	b.P("}")
+	b.P0()
+
+	b.P("// Number of bytes of a length-delimited value of length bytes, including its length prefix")
+	b.P("function bytes_size(uint256 length) internal pure returns (uint64) {")
+	b.Indent()
+	b.P("return varint_size(uint64(length)) + uint64(length);")
+	b.Unindent()
+	b.P("}")
+	b.P0()
+}
+
+// GenerateEncodedSize generates the encoded_size function of a codec library
+func (sg *SizeGenerator) GenerateEncodedSize(structName string, fields []*descriptorpb.FieldDescriptorProto, fieldNameMap map[int32]string, b *WriteableBuffer) error {
+	prefix := libraryPrefix(structName)
+	presenceGen := NewPresenceGenerator(sg.g)
+
+	b.P(fmt.Sprintf("function encoded_size(%s memory instance) internal pure returns (uint64) {", structName))
+	b.Indent()
+	if sg.g.unknownFieldsFlag == unknownFieldsFlagPreserve {
+		b.P("uint64 size = uint64(instance._unknown.length);")
+	} else {
+		b.P("uint64 size = 0;")
+	}
+
+	for _, field := range fields {
+		fieldName := fieldNameMap[field.GetNumber()]
+		fieldType := field.GetType()
+		keySize := varintSize(uint64(field.GetNumber()) << 3)
+
+		if isFieldRepeated(field) {
+			if sg.g.isFieldPacked(field) {
+				// Packed elements follow the key and a single byte length
+				elementSize, err := sg.scalarSize(field, fmt.Sprintf("instance.%s[i]", fieldName), prefix)
+				if err != nil {
+					return errors.New(err.Error() + ": " + structName + "." + fieldName)
+				}
+				b.P(fmt.Sprintf("if (instance.%s.length > 0) {", fieldName))
+				b.Indent()
+				b.P(fmt.Sprintf("size += %d;", keySize+1))
+				b.P(fmt.Sprintf("for (uint64 i = 0; i < instance.%s.length; i++) {", fieldName))
+				b.Indent()
+				b.P(fmt.Sprintf("size += %s;", elementSize))
+				b.Unindent()
+				b.P("}")
+				b.Unindent()
+				b.P("}")
+				continue
+			}
+
+			if sg.g.isFieldExpanded(field) {
+				// Each element follows its own key
+				elementSize, err := sg.scalarSize(field, fmt.Sprintf("instance.%s[i]", fieldName), prefix)
+				if err != nil {
+					return errors.New(err.Error() + ": " + structName + "." + fieldName)
+				}
+				b.P(fmt.Sprintf("for (uint64 i = 0; i < instance.%s.length; i++) {", fieldName))
+				b.Indent()
+				b.P(fmt.Sprintf("size += %d + %s;", keySize, elementSize))
+				b.Unindent()
+				b.P("}")
+				continue
+			}
+
+			// Each element is a message following its key and a single byte length
+			codecName := fmt.Sprintf("%sListCodec", strings.Title(fieldName))
+			if fieldType != descriptorpb.FieldDescriptorProto_TYPE_STRING && fieldType != descriptorpb.FieldDescriptorProto_TYPE_BYTES {
+				typeName, err := sg.g.getSolTypeName(field)
+				if err != nil {
+					return err
+				}
+				codecName = CodecLibraryName(typeName)
+			}
+			b.P(fmt.Sprintf("for (uint64 i = 0; i < instance.%s.length; i++) {", fieldName))
+			b.Indent()
+			b.P(fmt.Sprintf("size += %d + %s.encoded_size(instance.%s[i]);", keySize+1, codecName, fieldName))
+			b.Unindent()
+			b.P("}")
+			continue
+		}
+
+		var condition, valueSize string
+		switch {
+		case fieldType == descriptorpb.FieldDescriptorProto_TYPE_MESSAGE && sg.g.isRecursiveField(field):
+			// Recursive fields already hold the encoded message
+			condition = fmt.Sprintf("instance.%s.length > 0", fieldName)
+			valueSize = fmt.Sprintf("%sbytes_size(instance.%s.length)", prefix, fieldName)
+		case fieldType == descriptorpb.FieldDescriptorProto_TYPE_MESSAGE:
+			// The message follows a single byte length
+			typeName, err := sg.g.getSolTypeName(field)
+			if err != nil {
+				return err
+			}
+			condition = fmt.Sprintf("%s.encoded_size(instance.%s) > 0", CodecLibraryName(typeName), fieldName)
+			valueSize = fmt.Sprintf("1 + %s.encoded_size(instance.%s)", CodecLibraryName(typeName), fieldName)
+		case fieldType == descriptorpb.FieldDescriptorProto_TYPE_STRING || fieldType == descriptorpb.FieldDescriptorProto_TYPE_BYTES:
+			solType, fixedSize, err := fieldTypeOverride(field)
+			if err != nil {
+				return errors.New(err.Error() + ": " + structName + "." + fieldName)
+			}
+			switch {
+			case fixedSize > 0:
+				condition = fmt.Sprintf("instance.%s != %s(0)", fieldName, solType)
+				valueSize = fmt.Sprintf("%d", varintSize(uint64(fixedSize))+fixedSize)
+			case isLargeIntegerType(solType):
+				condition = fmt.Sprintf("instance.%s != 0", fieldName)
+				valueSize = fmt.Sprintf("%sbytes_size(%sencode_decimal_%s(instance.%s).length)", prefix, prefix, solType, fieldName)
+			case fieldType == descriptorpb.FieldDescriptorProto_TYPE_STRING && solType != "bytes":
+				condition = fmt.Sprintf("bytes(instance.%s).length > 0", fieldName)
+				valueSize = fmt.Sprintf("%sbytes_size(bytes(instance.%s).length)", prefix, fieldName)
+			default:
+				condition = fmt.Sprintf("instance.%s.length > 0", fieldName)
+				valueSize = fmt.Sprintf("%sbytes_size(instance.%s.length)", prefix, fieldName)
+			}
+		default:
+			var err error
+			valueSize, err = sg.scalarSize(field, "instance."+fieldName, prefix)
+			if err != nil {
+				return errors.New(err.Error() + ": " + structName + "." + fieldName)
+			}
+			switch fieldType {
+			case descriptorpb.FieldDescriptorProto_TYPE_ENUM:
+				typeName, err := sg.g.getSolTypeName(field)
+				if err != nil {
+					return err
+				}
+				condition = fmt.Sprintf("instance.%s != %s(0)", fieldName, typeName)
+			case descriptorpb.FieldDescriptorProto_TYPE_BOOL:
+				condition = fmt.Sprintf("instance.%s != false", fieldName)
+			default:
+				condition = fmt.Sprintf("instance.%s != 0", fieldName)
+			}
+		}
+
+		b.P(fmt.Sprintf("if (%s) {", presenceGen.encodeCondition(field, fieldName, condition)))
+		b.Indent()
+		b.P(fmt.Sprintf("size += %d + %s;", keySize, valueSize))
+		b.Unindent()
+		b.P("}")
+	}
+
+	b.P("return size;")
+	b.Unindent()
+	b.P("}")
+	b.P("")
+
+	return nil
+}
+
+// scalarSize returns the expression of the encoded size of a numeric, bool or enum value
+func (sg *SizeGenerator) scalarSize(field *descriptorpb.FieldDescriptorProto, value string, prefix string) (string, error) {
+	switch field.GetType() {
+	case descriptorpb.FieldDescriptorProto_TYPE_INT32,
+		descriptorpb.FieldDescriptorProto_TYPE_INT64:
+		// Negative values are sign extended to ten bytes
+		return fmt.Sprintf("%svarint_size(uint64(int64(%s)))", prefix, value), nil
+	case descriptorpb.FieldDescriptorProto_TYPE_UINT32,
+		descriptorpb.FieldDescriptorProto_TYPE_UINT64:
+		return fmt.Sprintf("%svarint_size(%s)", prefix, value), nil
+	case descriptorpb.FieldDescriptorProto_TYPE_ENUM:
+		// Enum values are never negative
+		return fmt.Sprintf("%svarint_size(uint64(%s))", prefix, value), nil
+	case descriptorpb.FieldDescriptorProto_TYPE_SINT32,
+		descriptorpb.FieldDescriptorProto_TYPE_SINT64:
+		return fmt.Sprintf("%szigzag_size(%s)", prefix, value), nil
+	case descriptorpb.FieldDescriptorProto_TYPE_FIXED32,
+		descriptorpb.FieldDescriptorProto_TYPE_SFIXED32,
+		descriptorpb.FieldDescriptorProto_TYPE_FLOAT:
+		return "4", nil
+	case descriptorpb.FieldDescriptorProto_TYPE_FIXED64,
+		descriptorpb.FieldDescriptorProto_TYPE_SFIXED64,
+		descriptorpb.FieldDescriptorProto_TYPE_DOUBLE:
+		return "8", nil
+	case descriptorpb.FieldDescriptorProto_TYPE_BOOL:
+		return "1", nil
+	default:
+		return "", errors.New("unsupported field type: " + field.GetType().String())
+	}
+}
+
+// varintSize returns the number of bytes of the varint encoding of value
+func varintSize(value uint64) int {
+	size := 1
+	for value >= 0x80 {
+		value >>= 7
+		size++
+	}
+	return size
+}
@@ -0,0 +1,109 @@
+package generator
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// solidityVersionPattern matches the first version of a specifier, e.g. "^0.8.19", "^0.7" or ">=0.7.0 <0.9.0"
+var solidityVersionPattern = regexp.MustCompile(`^(\^|~|>=|>|=)?\s*0\.(\d+)(?:\.(\d+))?`)
+
+// solidityVersion is the compiler version targeted by the generated code
+type solidityVersion struct {
+	// Version specifier written to the pragma
+	specifier string
+	// Lowest compiler version matched by the specifier (0.minor.patch)
+	minor int
+	patch int
+}
+
+func toSolidityVersion(s string) (solidityVersion, error) {
+	specifier := strings.TrimSpace(s)
+	match := solidityVersionPattern.FindStringSubmatch(specifier)
+	if match == nil {
+		return solidityVersion{}, fmt.Errorf("invalid solidity_version %s, expected a version specifier such as ^0.8.19, ^0.7 or >=0.7.0 <0.9.0", s)
+	}
+
+	// A missing patch version matches every patch version, starting with 0
+	minor, _ := strconv.Atoi(match[2])
+	patch := 0
+	if len(match[3]) > 0 {
+		patch, _ = strconv.Atoi(match[3])
+	}
+	if minor < 6 {
+		return solidityVersion{}, fmt.Errorf("unsupported solidity_version %s, the generated code requires 0.6.0 or later", s)
+	}
+
+	return solidityVersion{
+		specifier: specifier,
+		minor:     minor,
+		patch:     patch,
+	}, nil
+}
+
+// atLeast checks if every compiler matched by the specifier is at least 0.minor.patch
+func (v solidityVersion) atLeast(minor, patch int) bool {
+	return v.minor > minor || (v.minor == minor && v.patch >= patch)
+}
+
+// supportsUnchecked checks for unchecked blocks, which also means arithmetic is checked by default (0.8.0)
+func (v solidityVersion) supportsUnchecked() bool {
+	return v.atLeast(8, 0)
+}
+
+// supportsCustomErrors checks for error declarations and revert statements using them (0.8.4)
+func (v solidityVersion) supportsCustomErrors() bool {
+	return v.atLeast(8, 4)
+}
+
+// supportsBytesConcat checks for bytes.concat (0.8.4)
+func (v solidityVersion) supportsBytesConcat() bool {
+	return v.atLeast(8, 4)
+}
+
+// supportsMcopy checks for the mcopy instruction in inline assembly (0.8.24), which also needs a Cancun EVM target
+func (v solidityVersion) supportsMcopy() bool {
+	return v.atLeast(8, 24)
+}
+
+// generatePragmas generates the version pragma, and the ABI coder pragma for compilers where it is not the default
+func (v solidityVersion) generatePragmas(b *WriteableBuffer) {
+	b.P(fmt.Sprintf("pragma solidity %s;", v.specifier))
+	if !v.atLeast(8, 0) {
+		b.P(SolidityABIString)
+	}
+}
+
+// evmVersions lists the EVM versions accepted by solc's --evm-version, oldest first
+var evmVersions = []string{
+	"homestead", "tangerineWhistle", "spuriousDragon", "byzantium", "constantinople", "petersburg",
+	"istanbul", "berlin", "london", "paris", "shanghai", "cancun", "prague", "osaka",
+}
+
+// evmVersion is the EVM version the generated code is compiled for, or unknown if left to the compiler default
+type evmVersion struct {
+	name  string
+	index int
+}
+
+func toEVMVersion(s string) (evmVersion, error) {
+	for index, name := range evmVersions {
+		if s == name {
+			return evmVersion{name: name, index: index}, nil
+		}
+	}
+	return evmVersion{}, fmt.Errorf("invalid evm_version %s, expected one of %s", s, strings.Join(evmVersions, ", "))
+}
+
+// known checks if the EVM version was set, as opposed to left to the compiler default
+func (e evmVersion) known() bool {
+	return len(e.name) > 0
+}
+
+// atLeast checks if the EVM version is set and at least the named one
+func (e evmVersion) atLeast(name string) bool {
+	other, err := toEVMVersion(name)
+	return err == nil && e.known() && e.index >= other.index
+}
@@ -0,0 +1,130 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestToSolidityVersion(t *testing.T) {
+	tests := []struct {
+		specifier string
+		want      solidityVersion
+	}{
+		{"^0.8.19", solidityVersion{specifier: "^0.8.19", minor: 8, patch: 19}},
+		{"0.7.6", solidityVersion{specifier: "0.7.6", minor: 7, patch: 6}},
+		{"~0.6.12", solidityVersion{specifier: "~0.6.12", minor: 6, patch: 12}},
+		{">=0.7.0 <0.9.0", solidityVersion{specifier: ">=0.7.0 <0.9.0", minor: 7, patch: 0}},
+		{"> 0.8.4", solidityVersion{specifier: "> 0.8.4", minor: 8, patch: 4}},
+		{"=0.8.24", solidityVersion{specifier: "=0.8.24", minor: 8, patch: 24}},
+		{"^0.7", solidityVersion{specifier: "^0.7", minor: 7, patch: 0}},
+		{" ^0.8.4 ", solidityVersion{specifier: "^0.8.4", minor: 8, patch: 4}},
+	}
+	for _, test := range tests {
+		got, err := toSolidityVersion(test.specifier)
+		if err != nil {
+			t.Errorf("toSolidityVersion(%q): %v", test.specifier, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("toSolidityVersion(%q) = %+v, want %+v", test.specifier, got, test.want)
+		}
+	}
+}
+
+func TestToSolidityVersionRejectsInvalid(t *testing.T) {
+	tests := []struct {
+		specifier string
+		err       string
+	}{
+		{"", "invalid solidity_version"},
+		{"latest", "invalid solidity_version"},
+		{"<0.9.0", "invalid solidity_version"},
+		{"1.0.0", "invalid solidity_version"},
+		{"^0.5.17", "requires 0.6.0 or later"},
+		{"0.4", "requires 0.6.0 or later"},
+	}
+	for _, test := range tests {
+		_, err := toSolidityVersion(test.specifier)
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("toSolidityVersion(%q): got error %v, want %q", test.specifier, err, test.err)
+		}
+	}
+}
+
+func TestSolidityVersionFeatures(t *testing.T) {
+	tests := []struct {
+		specifier                              string
+		unchecked, customErrors, concat, mcopy bool
+	}{
+		{"^0.6.12", false, false, false, false},
+		{"^0.7", false, false, false, false},
+		{"^0.8.0", true, false, false, false},
+		{"^0.8.4", true, true, true, false},
+		{"^0.8.23", true, true, true, false},
+		{"^0.8.24", true, true, true, true},
+	}
+	for _, test := range tests {
+		v, err := toSolidityVersion(test.specifier)
+		if err != nil {
+			t.Fatalf("toSolidityVersion(%q): %v", test.specifier, err)
+		}
+		if v.supportsUnchecked() != test.unchecked {
+			t.Errorf("%s: supportsUnchecked() = %v", test.specifier, !test.unchecked)
+		}
+		if v.supportsCustomErrors() != test.customErrors {
+			t.Errorf("%s: supportsCustomErrors() = %v", test.specifier, !test.customErrors)
+		}
+		if v.supportsBytesConcat() != test.concat {
+			t.Errorf("%s: supportsBytesConcat() = %v", test.specifier, !test.concat)
+		}
+		if v.supportsMcopy() != test.mcopy {
+			t.Errorf("%s: supportsMcopy() = %v", test.specifier, !test.mcopy)
+		}
+	}
+}
+
+func TestSolidityVersionPragmas(t *testing.T) {
+	tests := []struct {
+		specifier string
+		want      string
+	}{
+		{"^0.7", "pragma solidity ^0.7;\n" + SolidityABIString + "\n"},
+		{"^0.8.19", "pragma solidity ^0.8.19;\n"},
+	}
+	for _, test := range tests {
+		v, err := toSolidityVersion(test.specifier)
+		if err != nil {
+			t.Fatalf("toSolidityVersion(%q): %v", test.specifier, err)
+		}
+		b := NewWriteableBuffer()
+		v.generatePragmas(b)
+		if got := b.String(); got != test.want {
+			t.Errorf("%s: pragmas %q, want %q", test.specifier, got, test.want)
+		}
+	}
+}
+
+func TestMcopyNeedsCompilerAndEVMSupport(t *testing.T) {
+	tests := []struct {
+		parameter string
+		mcopy     bool
+	}{
+		{"solidity_version=^0.8.24,evm_version=cancun", true},
+		{"solidity_version=^0.8.24,evm_version=prague", true},
+		{"solidity_version=^0.8.24,evm_version=shanghai", false},
+		{"solidity_version=^0.8.24", false},
+		{"solidity_version=^0.8.23,evm_version=cancun", false},
+		{"solidity_version=^0.7,evm_version=cancun", false},
+	}
+	for _, test := range tests {
+		// Preserved unknown fields make encoders copy bytes too
+		response := generateWithParameter(t, test.parameter+",generate=all,unknown_fields=preserve", proto3OptionalFile())
+		content := response.GetFile()[0].GetContent()
+		if got := strings.Count(content, "mcopy(dst, src, length)"); (got == 2) != test.mcopy || (got != 0 && got != 2) {
+			t.Errorf("%s: %d mcopy instructions, want mcopy %v in both copy_bytes and write_bytes", test.parameter, got, test.mcopy)
+		}
+		if loop := strings.Contains(content, "mstore(add(dst, i), mload(add(src, i)))"); loop == test.mcopy {
+			t.Errorf("%s: word copy loop used %v, want %v", test.parameter, loop, !test.mcopy)
+		}
+	}
+}
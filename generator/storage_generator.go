@@ -0,0 +1,84 @@
+package generator
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// StorageGenerator handles generation of helpers writing messages to storage structs
+type StorageGenerator struct {
+	g *Generator
+}
+
+// NewStorageGenerator creates a new storage generator
+func NewStorageGenerator(g *Generator) *StorageGenerator {
+	return &StorageGenerator{
+		g: g,
+	}
+}
+
+// GenerateStore generates store, which writes a memory struct, such as a decoded message, to a storage struct.
+// Arrays of structs and strings cannot be assigned from memory to storage, so they are cleared and refilled element by element.
+func (sg *StorageGenerator) GenerateStore(structName string, descriptor *descriptorpb.DescriptorProto, fieldNameMap map[int32]string, b *WriteableBuffer) error {
+	b.P(fmt.Sprintf("function store(%s memory value, %s storage out) internal {", structName, structName))
+	b.Indent()
+	for _, field := range descriptor.GetField() {
+		fieldName := fieldNameMap[field.GetNumber()]
+
+		switch {
+		case sg.g.isMapField(field, descriptor):
+			// Map entries are held in wrapper messages of the main library
+			wrapperName := CreateMapEntryWrapperName(fieldName)
+			sg.generateArrayStore(fieldName, fmt.Sprintf("%sCodec.store(value.%s[i], out.%s[i]);", wrapperName, fieldName, fieldName), b)
+		case isEmbeddedMessageField(field) && isFieldRepeated(field):
+			typeName, err := sg.g.getSolTypeName(field)
+			if err != nil {
+				return err
+			}
+			sg.generateArrayStore(fieldName, fmt.Sprintf("%s.store(value.%s[i], out.%s[i]);", CodecLibraryName(typeName), fieldName, fieldName), b)
+		case isEmbeddedMessageField(field) && !sg.g.isRecursiveField(field):
+			typeName, err := sg.g.getSolTypeName(field)
+			if err != nil {
+				return err
+			}
+			b.P(fmt.Sprintf("%s.store(value.%s, out.%s);", CodecLibraryName(typeName), fieldName, fieldName))
+		case isFieldRepeated(field) && (field.GetType() == descriptorpb.FieldDescriptorProto_TYPE_STRING ||
+			field.GetType() == descriptorpb.FieldDescriptorProto_TYPE_BYTES ||
+			field.GetType() == descriptorpb.FieldDescriptorProto_TYPE_MESSAGE):
+			// Elements are copied as a whole, which storage supports for strings, bytes and flat structs
+			b.P(fmt.Sprintf("delete out.%s;", fieldName))
+			b.P(fmt.Sprintf("for (uint256 i = 0; i < value.%s.length; i++) {", fieldName))
+			b.Indent()
+			b.P(fmt.Sprintf("out.%s.push(value.%s[i]);", fieldName, fieldName))
+			b.Unindent()
+			b.P("}")
+		default:
+			// Value types, strings, bytes and arrays of value types are assigned directly
+			b.P(fmt.Sprintf("out.%s = value.%s;", fieldName, fieldName))
+		}
+		if sg.g.hasFieldPresence(field) {
+			member := presenceMemberName(fieldName)
+			b.P(fmt.Sprintf("out.%s = value.%s;", member, member))
+		}
+	}
+	if sg.g.unknownFieldsFlag == unknownFieldsFlagPreserve {
+		b.P("out._unknown = value._unknown;")
+	}
+	b.Unindent()
+	b.P("}")
+	b.P0()
+
+	return nil
+}
+
+// generateArrayStore generates clearing a storage array of structs and refilling it with storeElement for each element
+func (sg *StorageGenerator) generateArrayStore(fieldName string, storeElement string, b *WriteableBuffer) {
+	b.P(fmt.Sprintf("delete out.%s;", fieldName))
+	b.P(fmt.Sprintf("for (uint256 i = 0; i < value.%s.length; i++) {", fieldName))
+	b.Indent()
+	b.P(fmt.Sprintf("out.%s.push();", fieldName))
+	b.P(storeElement)
+	b.Unindent()
+	b.P("}")
+}
@@ -0,0 +1,23 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStorageHelpersGenerateStore(t *testing.T) {
+	for _, parameter := range []string{"generate=decoder", "generate=encoder", "generate=all"} {
+		content := generateWithParameter(t, parameter+",storage_helpers=true", proto3OptionalFile()).GetFile()[0].GetContent()
+		if !strings.Contains(content, "function store(Optional.Item memory value, Optional.Item storage out) internal {") {
+			t.Errorf("%s: store not generated", parameter)
+		}
+		if !strings.Contains(content, "out.count = value.count;") || !strings.Contains(content, "out._has_count = value._has_count;") {
+			t.Errorf("%s: store does not copy the field and its presence", parameter)
+		}
+	}
+
+	content := generateWithParameter(t, "generate=all", proto3OptionalFile()).GetFile()[0].GetContent()
+	if strings.Contains(content, "function store(") {
+		t.Errorf("store generated without storage_helpers")
+	}
+}
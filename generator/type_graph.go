@@ -0,0 +1,175 @@
+package generator
+
+import (
+	"sort"
+	"strings"
+
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// typeGraph is the graph of singular embedded message fields between messages.
+// Solidity rejects structs that contain themselves other than through a dynamic array,
+// so every cycle in this graph has to be broken before structs can be emitted.
+type typeGraph struct {
+	// Message full names in declaration order, for deterministic traversal
+	names []string
+	// Message full name -> singular embedded message fields
+	edges map[string][]*descriptorpb.FieldDescriptorProto
+	// Message full name -> all embedded message fields, singular and repeated
+	messageFields map[string][]*descriptorpb.FieldDescriptorProto
+}
+
+// newTypeGraph builds the type graph of all messages in the given files, including nested messages
+func newTypeGraph(protoFiles []*descriptorpb.FileDescriptorProto) *typeGraph {
+	tg := &typeGraph{
+		edges:         make(map[string][]*descriptorpb.FieldDescriptorProto),
+		messageFields: make(map[string][]*descriptorpb.FieldDescriptorProto),
+	}
+	for _, protoFile := range protoFiles {
+		for _, msg := range protoFile.GetMessageType() {
+			tg.addMessage(protoFile.GetPackage(), msg)
+		}
+	}
+	return tg
+}
+
+// addMessage adds a message and its nested messages to the graph
+func (tg *typeGraph) addMessage(scope string, msg *descriptorpb.DescriptorProto) {
+	fullName := msg.GetName()
+	if len(scope) > 0 {
+		fullName = scope + "." + fullName
+	}
+
+	tg.names = append(tg.names, fullName)
+	for _, field := range msg.GetField() {
+		if !isEmbeddedMessageField(field) {
+			continue
+		}
+		tg.messageFields[fullName] = append(tg.messageFields[fullName], field)
+		if !isFieldRepeated(field) {
+			tg.edges[fullName] = append(tg.edges[fullName], field)
+		}
+	}
+
+	for _, nested := range msg.GetNestedType() {
+		tg.addMessage(fullName, nested)
+	}
+}
+
+// recursiveFields returns the fields whose message type leads back to the message containing them.
+// These are the fields of the graph that lie on a cycle.
+func (tg *typeGraph) recursiveFields() map[*descriptorpb.FieldDescriptorProto]bool {
+	component := tg.components(tg.edges)
+
+	// A field is recursive if its message type is in the same component as the containing message
+	recursive := make(map[*descriptorpb.FieldDescriptorProto]bool)
+	for _, name := range tg.names {
+		for _, field := range tg.edges[name] {
+			target := strings.TrimPrefix(field.GetTypeName(), ".")
+			if targetComponent, ok := component[target]; ok && targetComponent == component[name] {
+				recursive[field] = true
+			}
+		}
+	}
+	return recursive
+}
+
+// abiRecursiveMessages returns the messages whose struct reaches a cycle of structs, through dynamic arrays since
+// recursive fields are held as bytes. Such structs can be declared, but the ABI coder rejects recursive types,
+// so neither they nor the structs containing them can be passed to abi.encode or abi.decode.
+func (tg *typeGraph) abiRecursiveMessages(recursive map[*descriptorpb.FieldDescriptorProto]bool) map[string]bool {
+	// Struct members of each message, leaving out the recursive fields held as bytes
+	members := make(map[string][]*descriptorpb.FieldDescriptorProto)
+	for _, name := range tg.names {
+		for _, field := range tg.messageFields[name] {
+			if !recursive[field] {
+				members[name] = append(members[name], field)
+			}
+		}
+	}
+	component := tg.components(members)
+
+	// Messages on a cycle share their component with a member, or are their own member
+	componentSize := make(map[int]int)
+	for _, name := range tg.names {
+		componentSize[component[name]]++
+	}
+	result := make(map[string]bool)
+	for _, name := range tg.names {
+		for _, field := range members[name] {
+			target := strings.TrimPrefix(field.GetTypeName(), ".")
+			if target == name || (componentSize[component[name]] > 1 && component[target] == component[name]) {
+				result[name] = true
+			}
+		}
+	}
+
+	// Messages with a member reaching a cycle reach it too. Components are numbered in reverse topological
+	// order, members before the messages containing them, so a single pass in component order suffices.
+	names := make([]string, len(tg.names))
+	copy(names, tg.names)
+	sort.SliceStable(names, func(i, j int) bool { return component[names[i]] < component[names[j]] })
+	for _, name := range names {
+		for _, field := range members[name] {
+			if result[strings.TrimPrefix(field.GetTypeName(), ".")] {
+				result[name] = true
+			}
+		}
+	}
+	return result
+}
+
+// components returns the strongly connected component of each message in the graph of the given fields,
+// found with Tarjan's algorithm. Components are numbered in reverse topological order.
+func (tg *typeGraph) components(edges map[string][]*descriptorpb.FieldDescriptorProto) map[string]int {
+	index := make(map[string]int)
+	lowLink := make(map[string]int)
+	onStack := make(map[string]bool)
+	component := make(map[string]int)
+	var stack []string
+	nextIndex := 0
+	nextComponent := 0
+
+	var visit func(name string)
+	visit = func(name string) {
+		index[name] = nextIndex
+		lowLink[name] = nextIndex
+		nextIndex++
+		stack = append(stack, name)
+		onStack[name] = true
+
+		for _, field := range edges[name] {
+			target := strings.TrimPrefix(field.GetTypeName(), ".")
+			if _, visited := index[target]; !visited {
+				visit(target)
+				if lowLink[target] < lowLink[name] {
+					lowLink[name] = lowLink[target]
+				}
+			} else if onStack[target] && index[target] < lowLink[name] {
+				lowLink[name] = index[target]
+			}
+		}
+
+		// Pop the strongly connected component rooted at this message
+		if lowLink[name] == index[name] {
+			for {
+				top := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[top] = false
+				component[top] = nextComponent
+				if top == name {
+					break
+				}
+			}
+			nextComponent++
+		}
+	}
+
+	for _, name := range tg.names {
+		if _, visited := index[name]; !visited {
+			visit(name)
+		}
+	}
+
+	return component
+}
@@ -233,9 +233,9 @@ func typeToDecodeSol(fType descriptorpb.FieldDescriptorProto_Type) (string, erro
 	case descriptorpb.FieldDescriptorProto_TYPE_INT32:
 		return "int32", nil
 	case descriptorpb.FieldDescriptorProto_TYPE_FIXED64:
-		return "uint64", nil
+		return "fixed64", nil
 	case descriptorpb.FieldDescriptorProto_TYPE_FIXED32:
-		return "uint32", nil
+		return "fixed32", nil
 	case descriptorpb.FieldDescriptorProto_TYPE_BOOL:
 		return "bool", nil
 	case descriptorpb.FieldDescriptorProto_TYPE_STRING:
@@ -251,13 +251,13 @@ func typeToDecodeSol(fType descriptorpb.FieldDescriptorProto_Type) (string, erro
 	case descriptorpb.FieldDescriptorProto_TYPE_ENUM:
 		return "", errors.New("unsupported field type TYPE_ENUM")
 	case descriptorpb.FieldDescriptorProto_TYPE_SFIXED32:
-		return "int32", nil
+		return "sfixed32", nil
 	case descriptorpb.FieldDescriptorProto_TYPE_SFIXED64:
-		return "int64", nil
+		return "sfixed64", nil
 	case descriptorpb.FieldDescriptorProto_TYPE_SINT32:
-		return "int32", nil
+		return "sint32", nil
 	case descriptorpb.FieldDescriptorProto_TYPE_SINT64:
-		return "int64", nil
+		return "sint64", nil
 	default:
 		return "", errors.New("unsupported field type: " + fType.String())
 	}
@@ -335,9 +335,22 @@ func isFieldRepeated(field *descriptorpb.FieldDescriptorProto) bool {
 	return field.GetLabel() == descriptorpb.FieldDescriptorProto_LABEL_REPEATED
 }
 
-// isFieldPacked checks if a field is packed
-func isFieldPacked(field *descriptorpb.FieldDescriptorProto) bool {
-	return field.GetOptions().GetPacked()
+// isEmbeddedMessageField checks if a field is a message field with a generated codec library.
+// Google protobuf well-known types are represented by shared structs without codecs.
+func isEmbeddedMessageField(field *descriptorpb.FieldDescriptorProto) bool {
+	return field.GetType() == descriptorpb.FieldDescriptorProto_TYPE_MESSAGE &&
+		!strings.HasPrefix(field.GetTypeName(), ".google.protobuf.")
+}
+
+// getMaxFieldNumber returns the largest field number among the given fields
+func getMaxFieldNumber(fields []*descriptorpb.FieldDescriptorProto) int32 {
+	var maxFieldNumber int32
+	for _, field := range fields {
+		if field.GetNumber() > maxFieldNumber {
+			maxFieldNumber = field.GetNumber()
+		}
+	}
+	return maxFieldNumber
 }
 
 // toSolWireType converts protobuf field type to Solidity wire type
@@ -371,6 +384,35 @@ func toSolWireType(field *descriptorpb.FieldDescriptorProto) (string, error) {
 	}
 }
 
+// wireTypeNumber returns the numeric protobuf wire type of a field type
+func wireTypeNumber(fType descriptorpb.FieldDescriptorProto_Type) (int, error) {
+	switch fType {
+	case descriptorpb.FieldDescriptorProto_TYPE_ENUM,
+		descriptorpb.FieldDescriptorProto_TYPE_INT32,
+		descriptorpb.FieldDescriptorProto_TYPE_INT64,
+		descriptorpb.FieldDescriptorProto_TYPE_UINT32,
+		descriptorpb.FieldDescriptorProto_TYPE_UINT64,
+		descriptorpb.FieldDescriptorProto_TYPE_SINT32,
+		descriptorpb.FieldDescriptorProto_TYPE_SINT64,
+		descriptorpb.FieldDescriptorProto_TYPE_BOOL:
+		return 0, nil
+	case descriptorpb.FieldDescriptorProto_TYPE_FIXED64,
+		descriptorpb.FieldDescriptorProto_TYPE_SFIXED64,
+		descriptorpb.FieldDescriptorProto_TYPE_DOUBLE:
+		return 1, nil
+	case descriptorpb.FieldDescriptorProto_TYPE_STRING,
+		descriptorpb.FieldDescriptorProto_TYPE_BYTES,
+		descriptorpb.FieldDescriptorProto_TYPE_MESSAGE:
+		return 2, nil
+	case descriptorpb.FieldDescriptorProto_TYPE_FIXED32,
+		descriptorpb.FieldDescriptorProto_TYPE_SFIXED32,
+		descriptorpb.FieldDescriptorProto_TYPE_FLOAT:
+		return 5, nil
+	default:
+		return 0, errors.New("unsupported field type: " + fType.String())
+	}
+}
+
 // toSolMessageOrEnumName extracts the message or enum name from a field
 func toSolMessageOrEnumName(field *descriptorpb.FieldDescriptorProto) (string, error) {
 	// Names take the form ".name", so remove the leading period
@@ -3,6 +3,7 @@ package generator
 import (
 	"fmt"
 	"strings"
+	"unicode"
 )
 
 // PackageToLibraryName converts a package name to Solidity library name format
@@ -36,6 +37,16 @@ func IsGoogleDependency(dependency string) bool {
 	return IsGoogleProtobufDependency(dependency) || IsGoogleAPIDependency(dependency)
 }
 
+// IsSolidityOptionsDependency checks if a dependency is the definition of the plugin's custom options
+func IsSolidityOptionsDependency(dependency string) bool {
+	return dependency == SolidityOptionsFile
+}
+
+// IsValidationRulesDependency checks if a dependency is the definition of protoc-gen-validate or buf.validate rules
+func IsValidationRulesDependency(dependency string) bool {
+	return dependency == "validate/validate.proto" || strings.HasPrefix(dependency, "buf/validate/")
+}
+
 // CreateListWrapperName creates a wrapper name for repeated fields
 func CreateListWrapperName(fieldName string) string {
 	return fmt.Sprintf("%sList", strings.Title(fieldName))
@@ -44,4 +55,32 @@ func CreateListWrapperName(fieldName string) string {
 // CreateMapEntryWrapperName creates a wrapper name for map entry fields
 func CreateMapEntryWrapperName(fieldName string) string {
 	return fmt.Sprintf("%sEntry", strings.Title(fieldName))
-}
\ No newline at end of file
+}
+
+// libraryPrefix returns the library qualifier of a library-qualified struct name, including the trailing dot
+// Example: "A2a_V1.Message" -> "A2a_V1."
+func libraryPrefix(qualifiedName string) string {
+	return qualifiedName[:strings.LastIndex(qualifiedName, ".")+1]
+}
+
+// CodecLibraryName returns the codec library name for a (possibly library-qualified) struct type name
+// Example: "A2a_V1.Message" -> "MessageCodec"
+func CodecLibraryName(typeName string) string {
+	return typeName[strings.LastIndex(typeName, ".")+1:] + "Codec"
+}
+
+// toSnakeCase converts a CamelCase name to snake_case
+// Example: "LabelsEntry" -> "labels_entry"
+func toSnakeCase(name string) string {
+	var sb strings.Builder
+	for i, r := range name {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(rune(name[i-1])) || unicode.IsDigit(rune(name[i-1]))) {
+				sb.WriteRune('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
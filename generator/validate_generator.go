@@ -0,0 +1,800 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// Extension numbers of the field rules of buf.validate, (buf.validate.field), and protoc-gen-validate, (validate.rules).
+// Both FieldRules messages number their per-type rules alike, except for protoc-gen-validate's ignore_empty rules,
+// which buf.validate replaced with its ignore rule and whose numbers it reuses for example values.
+const (
+	bufValidateFieldRules protowire.Number = 1159
+	pgvFieldRules         protowire.Number = 1071
+)
+
+// Numbers of the FieldRules fields that are not numeric rules
+const (
+	rulesBool     protowire.Number = 13
+	rulesString   protowire.Number = 14
+	rulesBytes    protowire.Number = 15
+	rulesEnum     protowire.Number = 16
+	rulesMessage  protowire.Number = 17 // protoc-gen-validate only
+	rulesRepeated protowire.Number = 18
+	rulesMap      protowire.Number = 19
+	rulesCel      protowire.Number = 23 // buf.validate only
+	rulesRequired protowire.Number = 25 // buf.validate only
+	rulesIgnore   protowire.Number = 27 // buf.validate only
+)
+
+// Values of buf.validate's ignore rule
+const (
+	ignoreIfUnpopulated  = 1
+	ignoreIfDefaultValue = 2
+	ignoreAlways         = 3
+)
+
+// numericRuleKind is the FieldRules field holding the rules of a numeric field type
+type numericRuleKind struct {
+	number protowire.Number
+	name   string
+}
+
+var numericRuleKinds = map[descriptorpb.FieldDescriptorProto_Type]numericRuleKind{
+	descriptorpb.FieldDescriptorProto_TYPE_INT32:    {3, "int32"},
+	descriptorpb.FieldDescriptorProto_TYPE_INT64:    {4, "int64"},
+	descriptorpb.FieldDescriptorProto_TYPE_UINT32:   {5, "uint32"},
+	descriptorpb.FieldDescriptorProto_TYPE_UINT64:   {6, "uint64"},
+	descriptorpb.FieldDescriptorProto_TYPE_SINT32:   {7, "sint32"},
+	descriptorpb.FieldDescriptorProto_TYPE_SINT64:   {8, "sint64"},
+	descriptorpb.FieldDescriptorProto_TYPE_FIXED32:  {9, "fixed32"},
+	descriptorpb.FieldDescriptorProto_TYPE_FIXED64:  {10, "fixed64"},
+	descriptorpb.FieldDescriptorProto_TYPE_SFIXED32: {11, "sfixed32"},
+	descriptorpb.FieldDescriptorProto_TYPE_SFIXED64: {12, "sfixed64"},
+}
+
+// ruleField is a field of a rules message, with its varint or fixed value, or its length-delimited content
+type ruleField struct {
+	number   protowire.Number
+	wireType protowire.Type
+	value    uint64
+	content  []byte
+}
+
+// parseRuleFields parses the fields of a rules message
+func parseRuleFields(b []byte) ([]ruleField, error) {
+	var fields []ruleField
+	for len(b) > 0 {
+		number, wireType, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		field := ruleField{number: number, wireType: wireType}
+		switch wireType {
+		case protowire.VarintType:
+			field.value, n = protowire.ConsumeVarint(b)
+		case protowire.Fixed32Type:
+			var value uint32
+			value, n = protowire.ConsumeFixed32(b)
+			field.value = uint64(value)
+		case protowire.Fixed64Type:
+			field.value, n = protowire.ConsumeFixed64(b)
+		case protowire.BytesType:
+			field.content, n = protowire.ConsumeBytes(b)
+		default:
+			n = protowire.ConsumeFieldValue(number, wireType, b)
+		}
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		b = b[n:]
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// fieldRules returns the FieldRules of a field from buf.validate, or else protoc-gen-validate, nil if it has none,
+// along with the extension number they were found under
+func fieldRules(field *descriptorpb.FieldDescriptorProto) ([]ruleField, protowire.Number, error) {
+	for _, number := range []protowire.Number{bufValidateFieldRules, pgvFieldRules} {
+		wireType, value, found := fieldOptionValue(field, number)
+		if !found {
+			continue
+		}
+		content, n := protowire.ConsumeBytes(value)
+		if wireType != protowire.BytesType || n < 0 {
+			return nil, 0, fmt.Errorf("field %s: malformed validation rules", field.GetName())
+		}
+		rules, err := parseRuleFields(content)
+		if err != nil {
+			return nil, 0, fmt.Errorf("field %s: malformed validation rules: %v", field.GetName(), err)
+		}
+		return rules, number, nil
+	}
+	return nil, 0, nil
+}
+
+// numericRuleValues returns the values of a numeric rule of a field type, which may be a packed list
+func numericRuleValues(fieldType descriptorpb.FieldDescriptorProto_Type, rule ruleField) ([]*big.Int, error) {
+	if rule.wireType != protowire.BytesType {
+		return []*big.Int{numericRuleValue(fieldType, rule.value)}, nil
+	}
+
+	wireType, err := wireTypeNumber(fieldType)
+	if err != nil {
+		return nil, err
+	}
+	var values []*big.Int
+	b := rule.content
+	for len(b) > 0 {
+		var value uint64
+		var n int
+		switch wireType {
+		case 1:
+			value, n = protowire.ConsumeFixed64(b)
+		case 5:
+			var value32 uint32
+			value32, n = protowire.ConsumeFixed32(b)
+			value = uint64(value32)
+		default:
+			value, n = protowire.ConsumeVarint(b)
+		}
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		b = b[n:]
+		values = append(values, numericRuleValue(fieldType, value))
+	}
+	return values, nil
+}
+
+// numericRuleValue converts the raw value of a numeric rule to the integer it holds for a field type
+func numericRuleValue(fieldType descriptorpb.FieldDescriptorProto_Type, value uint64) *big.Int {
+	switch fieldType {
+	case descriptorpb.FieldDescriptorProto_TYPE_INT32,
+		descriptorpb.FieldDescriptorProto_TYPE_INT64,
+		descriptorpb.FieldDescriptorProto_TYPE_SFIXED64,
+		descriptorpb.FieldDescriptorProto_TYPE_ENUM:
+		return big.NewInt(int64(value))
+	case descriptorpb.FieldDescriptorProto_TYPE_SFIXED32:
+		return big.NewInt(int64(int32(uint32(value))))
+	case descriptorpb.FieldDescriptorProto_TYPE_SINT32,
+		descriptorpb.FieldDescriptorProto_TYPE_SINT64:
+		return big.NewInt(protowire.DecodeZigZag(value))
+	}
+	return new(big.Int).SetUint64(value)
+}
+
+// solidityBytesLiteral returns a Solidity literal of b: a string literal if it is printable ASCII, a hex literal otherwise
+func solidityBytesLiteral(b []byte) string {
+	for _, c := range b {
+		if c < 0x20 || c > 0x7e || c == '"' || c == '\\' {
+			return fmt.Sprintf("hex\"%x\"", b)
+		}
+	}
+	return "\"" + string(b) + "\""
+}
+
+// validationCheck is a condition under which a value breaks a rule, and the identifier of that rule
+type validationCheck struct {
+	condition string
+	rule      string
+}
+
+// fieldValidation holds the validation of a field, as described by its rules
+type fieldValidation struct {
+	required    bool
+	ignoreEmpty bool // Skip the checks when the field has its default value
+	skip        bool // Skip all rules, including those of embedded messages
+	checks      []validationCheck
+}
+
+// ValidateGenerator handles generation of validation functions from buf.validate and protoc-gen-validate rules
+type ValidateGenerator struct {
+	g *Generator
+}
+
+// NewValidateGenerator creates a new validation generator
+func NewValidateGenerator(g *Generator) *ValidateGenerator {
+	return &ValidateGenerator{
+		g: g,
+	}
+}
+
+// GenerateValidationHelpers generates the main library helpers used by validation functions
+func (vg *ValidateGenerator) GenerateValidationHelpers(b *WriteableBuffer) {
+	b.P("// Returns the number of code points of a UTF-8 string, counting the bytes that do not continue a code point")
+	b.P("function utf8_length(string memory value) internal pure returns (uint256) {")
+	b.Indent()
+	b.P("bytes memory data = bytes(value);")
+	b.P("uint256 length = 0;")
+	b.P("for (uint256 i = 0; i < data.length; i++) {")
+	b.Indent()
+	b.P("if ((uint8(data[i]) & 0xC0) != 0x80) {")
+	b.Indent()
+	b.P("length++;")
+	b.Unindent()
+	b.P("}")
+	b.Unindent()
+	b.P("}")
+	b.P("return length;")
+	b.Unindent()
+	b.P("}")
+	b.P0()
+}
+
+// GenerateValidate generates validate_fields, which checks the rules of the fields of a message,
+// and validate, which also checks the embedded messages that differ from their default value.
+// Both return false and the field and rule identifier of the first rule broken.
+func (vg *ValidateGenerator) GenerateValidate(structName string, descriptor *descriptorpb.DescriptorProto, fieldNameMap map[int32]string, b *WriteableBuffer) error {
+	validations := make(map[int32]*fieldValidation)
+	for _, field := range descriptor.GetField() {
+		validation, err := vg.fieldValidation(field, fieldNameMap[field.GetNumber()], descriptor, structName)
+		if err != nil {
+			return err
+		}
+		validations[field.GetNumber()] = validation
+	}
+
+	b.P(fmt.Sprintf("function validate_fields(%s memory instance) internal pure returns (bool, string memory) {", structName))
+	b.Indent()
+	for _, field := range descriptor.GetField() {
+		fieldName := fieldNameMap[field.GetNumber()]
+		validation := validations[field.GetNumber()]
+		if validation.skip || (!validation.required && len(validation.checks) == 0) {
+			continue
+		}
+
+		unset, set, declaration, err := vg.presenceConditions(field, "instance."+fieldName)
+		if err != nil {
+			return err
+		}
+		if len(declaration) > 0 {
+			b.P("{")
+			b.Indent()
+			b.P(declaration)
+		}
+		if validation.required {
+			vg.generateRuleCheck(validationCheck{unset, "required"}, fieldName, b)
+		}
+		if len(validation.checks) > 0 {
+			if validation.ignoreEmpty {
+				b.P(fmt.Sprintf("if (%s) {", set))
+				b.Indent()
+			}
+			for _, check := range validation.checks {
+				vg.generateRuleCheck(check, fieldName, b)
+			}
+			if validation.ignoreEmpty {
+				b.Unindent()
+				b.P("}")
+			}
+		}
+		if len(declaration) > 0 {
+			b.Unindent()
+			b.P("}")
+		}
+	}
+	b.P("return (true, \"\");")
+	b.Unindent()
+	b.P("}")
+	b.P0()
+
+	// Embedded messages are validated with their own codec libraries
+	var embedded []*descriptorpb.FieldDescriptorProto
+	for _, field := range descriptor.GetField() {
+		if isEmbeddedMessageField(field) && !vg.g.isMapField(field, descriptor) && !vg.g.isRecursiveField(field) && !validations[field.GetNumber()].skip {
+			embedded = append(embedded, field)
+		}
+	}
+
+	b.P(fmt.Sprintf("function validate(%s memory instance) internal pure returns (bool, string memory) {", structName))
+	b.Indent()
+	if len(embedded) == 0 {
+		b.P("return validate_fields(instance);")
+		b.Unindent()
+		b.P("}")
+		b.P0()
+		return nil
+	}
+	b.P("bool valid;")
+	b.P("string memory reason;")
+	b.P("(valid, reason) = validate_fields(instance);")
+	vg.generateReturnReason(b)
+	for _, field := range embedded {
+		fieldName := fieldNameMap[field.GetNumber()]
+		typeName, err := vg.g.getSolTypeName(field)
+		if err != nil {
+			return err
+		}
+		codecName := CodecLibraryName(typeName)
+
+		if isFieldRepeated(field) {
+			b.P(fmt.Sprintf("for (uint256 i = 0; i < instance.%s.length; i++) {", fieldName))
+			b.Indent()
+			b.P(fmt.Sprintf("(valid, reason) = %s.validate(instance.%s[i]);", codecName, fieldName))
+			vg.generateReturnReason(b)
+			b.Unindent()
+			b.P("}")
+			continue
+		}
+
+		if vg.g.hasFieldPresence(field) {
+			b.P(fmt.Sprintf("if (instance.%s) {", presenceMemberName(fieldName)))
+			b.Indent()
+			b.P(fmt.Sprintf("(valid, reason) = %s.validate(instance.%s);", codecName, fieldName))
+			vg.generateReturnReason(b)
+			b.Unindent()
+			b.P("}")
+			continue
+		}
+
+		// Messages without presence are absent when they equal their default value
+		b.P("{")
+		b.Indent()
+		b.P(fmt.Sprintf("%s memory empty;", typeName))
+		b.P(fmt.Sprintf("if (!%s.equals(instance.%s, empty)) {", codecName, fieldName))
+		b.Indent()
+		b.P(fmt.Sprintf("(valid, reason) = %s.validate(instance.%s);", codecName, fieldName))
+		vg.generateReturnReason(b)
+		b.Unindent()
+		b.P("}")
+		b.Unindent()
+		b.P("}")
+	}
+	b.P("return (true, \"\");")
+	b.Unindent()
+	b.P("}")
+	b.P0()
+
+	return nil
+}
+
+// generateRuleCheck generates an early return of the rule identifier when the check's condition holds
+func (vg *ValidateGenerator) generateRuleCheck(check validationCheck, fieldName string, b *WriteableBuffer) {
+	b.P(fmt.Sprintf("if (%s) {", check.condition))
+	b.Indent()
+	b.P(fmt.Sprintf("return (false, \"%s: %s\");", fieldName, check.rule))
+	b.Unindent()
+	b.P("}")
+}
+
+// generateReturnReason generates an early return of the reason of a failed validation
+func (vg *ValidateGenerator) generateReturnReason(b *WriteableBuffer) {
+	b.P("if (!valid) {")
+	b.Indent()
+	b.P("return (false, reason);")
+	b.Unindent()
+	b.P("}")
+}
+
+// presenceConditions returns the conditions under which a field has, or does not have, its default value.
+// Embedded messages are compared to a default instance, which the returned declaration creates.
+func (vg *ValidateGenerator) presenceConditions(field *descriptorpb.FieldDescriptorProto, value string) (unset string, set string, declaration string, err error) {
+	if isFieldRepeated(field) {
+		return value + ".length == 0", value + ".length > 0", "", nil
+	}
+	if vg.g.hasFieldPresence(field) {
+		// Fields tracking their presence are set exactly when their presence member says so
+		dot := strings.LastIndex(value, ".")
+		member := value[:dot+1] + presenceMemberName(value[dot+1:])
+		return "!" + member, member, "", nil
+	}
+
+	switch field.GetType() {
+	case descriptorpb.FieldDescriptorProto_TYPE_MESSAGE:
+		if vg.g.isRecursiveField(field) {
+			return value + ".length == 0", value + ".length > 0", "", nil
+		}
+		typeName, err := vg.g.getSolTypeName(field)
+		if err != nil {
+			return "", "", "", err
+		}
+		declaration = fmt.Sprintf("%s memory empty;", typeName)
+		if !isEmbeddedMessageField(field) {
+			// Well-known types have no codec, so they are compared by their ABI encoding
+			equal := fmt.Sprintf("keccak256(abi.encode(%s)) == keccak256(abi.encode(empty))", value)
+			return equal, strings.Replace(equal, "==", "!=", 1), declaration, nil
+		}
+		equals := fmt.Sprintf("%s.equals(%s, empty)", CodecLibraryName(typeName), value)
+		return equals, "!" + equals, declaration, nil
+	case descriptorpb.FieldDescriptorProto_TYPE_STRING,
+		descriptorpb.FieldDescriptorProto_TYPE_BYTES:
+		solType, fixedSize, err := fieldTypeOverride(field)
+		if err != nil {
+			return "", "", "", err
+		}
+		switch {
+		case fixedSize > 0:
+			return fmt.Sprintf("%s == %s(0)", value, solType), fmt.Sprintf("%s != %s(0)", value, solType), "", nil
+		case isLargeIntegerType(solType):
+			return value + " == 0", value + " != 0", "", nil
+		case field.GetType() == descriptorpb.FieldDescriptorProto_TYPE_STRING && solType != "bytes":
+			return fmt.Sprintf("bytes(%s).length == 0", value), fmt.Sprintf("bytes(%s).length > 0", value), "", nil
+		}
+		return value + ".length == 0", value + ".length > 0", "", nil
+	case descriptorpb.FieldDescriptorProto_TYPE_BOOL:
+		return "!" + value, value, "", nil
+	case descriptorpb.FieldDescriptorProto_TYPE_ENUM:
+		return fmt.Sprintf("uint256(%s) == 0", value), fmt.Sprintf("uint256(%s) != 0", value), "", nil
+	}
+	return value + " == 0", value + " != 0", "", nil
+}
+
+// fieldValidation interprets the rules of a field.
+// Rules that cannot be checked on a struct member are rejected rather than ignored, so that no rule is silently lost.
+func (vg *ValidateGenerator) fieldValidation(field *descriptorpb.FieldDescriptorProto, fieldName string, descriptor *descriptorpb.DescriptorProto, structName string) (*fieldValidation, error) {
+	validation := &fieldValidation{}
+	rules, extension, err := fieldRules(field)
+	if err != nil || rules == nil {
+		return validation, err
+	}
+	bufValidate := extension == bufValidateFieldRules
+
+	value := "instance." + fieldName
+	for _, rule := range rules {
+		if bufValidate {
+			switch rule.number {
+			case rulesRequired:
+				validation.required = rule.value != 0
+				continue
+			case rulesIgnore:
+				switch rule.value {
+				case ignoreIfUnpopulated, ignoreIfDefaultValue:
+					validation.ignoreEmpty = true
+				case ignoreAlways:
+					validation.skip = true
+				}
+				continue
+			case rulesCel:
+				return nil, fmt.Errorf("validate does not support CEL rules: %s.%s", structName, fieldName)
+			}
+		}
+
+		var typeRules []ruleField
+		if rule.wireType == protowire.BytesType {
+			typeRules, err = parseRuleFields(rule.content)
+			if err != nil {
+				return nil, fmt.Errorf("malformed validation rules: %s.%s: %v", structName, fieldName, err)
+			}
+		}
+
+		var checks []validationCheck
+		ignoreEmpty := false
+		kindName := ""
+		switch {
+		case rule.number == rulesMessage && !bufValidate && field.GetType() == descriptorpb.FieldDescriptorProto_TYPE_MESSAGE:
+			for _, messageRule := range typeRules {
+				switch messageRule.number {
+				case 1:
+					validation.skip = messageRule.value != 0
+				case 2:
+					validation.required = validation.required || messageRule.value != 0
+				}
+			}
+		case rule.number == rulesRepeated && isFieldRepeated(field) && !vg.g.isMapField(field, descriptor):
+			kindName = "repeated"
+			checks, ignoreEmpty, err = vg.lengthChecks(kindName, typeRules, value+".length", 1, 2, 0, pgvRule(bufValidate, 5))
+		case rule.number == rulesMap && vg.g.isMapField(field, descriptor):
+			kindName = "map"
+			checks, ignoreEmpty, err = vg.lengthChecks(kindName, typeRules, value+".length", 1, 2, 0, pgvRule(bufValidate, 6))
+		case isFieldRepeated(field):
+			return nil, fmt.Errorf("validate does not support item rules of repeated fields: %s.%s", structName, fieldName)
+		case rule.number == rulesString && field.GetType() == descriptorpb.FieldDescriptorProto_TYPE_STRING:
+			kindName = "string"
+			checks, ignoreEmpty, err = vg.stringChecks(typeRules, value, structName, bufValidate)
+		case rule.number == rulesBytes && field.GetType() == descriptorpb.FieldDescriptorProto_TYPE_BYTES:
+			kindName = "bytes"
+			checks, ignoreEmpty, err = vg.bytesChecks(typeRules, value, bufValidate)
+		case rule.number == rulesEnum && field.GetType() == descriptorpb.FieldDescriptorProto_TYPE_ENUM:
+			kindName = "enum"
+			checks, err = vg.enumChecks(typeRules, value, bufValidate)
+		case rule.number == rulesBool && field.GetType() == descriptorpb.FieldDescriptorProto_TYPE_BOOL:
+			kindName = "bool"
+			for _, boolRule := range typeRules {
+				if bufValidate && boolRule.number == 2 {
+					continue // example
+				}
+				if boolRule.number != 1 {
+					return nil, fmt.Errorf("validate does not support bool rule %d: %s.%s", boolRule.number, structName, fieldName)
+				}
+				checks = append(checks, validationCheck{fmt.Sprintf("%s != %t", value, boolRule.value != 0), "bool.const"})
+			}
+		case rule.number == numericRuleKinds[field.GetType()].number:
+			kindName = numericRuleKinds[field.GetType()].name
+			checks, ignoreEmpty, err = vg.numericChecks(field.GetType(), kindName, typeRules, value, bufValidate)
+		default:
+			return nil, fmt.Errorf("validate does not support rules %d on a field of type %s: %s.%s", rule.number, field.GetType().String(), structName, fieldName)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%v: %s.%s", err, structName, fieldName)
+		}
+
+		if kindName == "string" || kindName == "bytes" {
+			if solType, _, _ := fieldTypeOverride(field); len(solType) > 0 {
+				return nil, fmt.Errorf("validate does not support %s rules on a field with Solidity type %s: %s.%s", kindName, solType, structName, fieldName)
+			}
+		}
+		validation.checks = append(validation.checks, checks...)
+		validation.ignoreEmpty = validation.ignoreEmpty || ignoreEmpty
+	}
+
+	return validation, nil
+}
+
+// bufValidateRule returns the number of a buf.validate only rule, 0 (no rule) for protoc-gen-validate
+func bufValidateRule(bufValidate bool, number protowire.Number) protowire.Number {
+	if bufValidate {
+		return number
+	}
+	return 0
+}
+
+// pgvRule returns the number of a protoc-gen-validate only rule, 0 (no rule) for buf.validate
+func pgvRule(bufValidate bool, number protowire.Number) protowire.Number {
+	if bufValidate {
+		return 0
+	}
+	return number
+}
+
+// lengthChecks returns the checks of the rules bounding a length, given the numbers of their fields, 0 for none
+func (vg *ValidateGenerator) lengthChecks(kindName string, rules []ruleField, length string, minNumber protowire.Number, maxNumber protowire.Number, exactNumber protowire.Number, ignoreEmptyNumber protowire.Number) ([]validationCheck, bool, error) {
+	var checks []validationCheck
+	ignoreEmpty := false
+	for _, rule := range rules {
+		switch rule.number {
+		case minNumber:
+			checks = append(checks, validationCheck{fmt.Sprintf("%s < %d", length, rule.value), kindName + "." + lengthRuleName(kindName, "min")})
+		case maxNumber:
+			checks = append(checks, validationCheck{fmt.Sprintf("%s > %d", length, rule.value), kindName + "." + lengthRuleName(kindName, "max")})
+		case exactNumber:
+			checks = append(checks, validationCheck{fmt.Sprintf("%s != %d", length, rule.value), kindName + ".len"})
+		case ignoreEmptyNumber:
+			ignoreEmpty = rule.value != 0
+		default:
+			return nil, false, fmt.Errorf("validate does not support %s rule %d", kindName, rule.number)
+		}
+	}
+	return checks, ignoreEmpty, nil
+}
+
+// lengthRuleName returns the name of the minimum or maximum length rule of a kind of rules
+func lengthRuleName(kindName string, bound string) string {
+	switch kindName {
+	case "repeated":
+		return bound + "_items"
+	case "map":
+		return bound + "_pairs"
+	}
+	return bound + "_len"
+}
+
+// stringChecks returns the checks of string rules. Lengths count code points, byte lengths count bytes.
+func (vg *ValidateGenerator) stringChecks(rules []ruleField, value string, structName string, bufValidate bool) ([]validationCheck, bool, error) {
+	codePoints := fmt.Sprintf("%sutf8_length(%s)", libraryPrefix(structName), value)
+	hash := fmt.Sprintf("keccak256(bytes(%s))", value)
+
+	var checks []validationCheck
+	var inValues, notInValues [][]byte
+	ignoreEmpty := false
+	for _, rule := range rules {
+		var check validationCheck
+		switch rule.number {
+		case 1:
+			check = validationCheck{fmt.Sprintf("%s != keccak256(%s)", hash, solidityBytesLiteral(rule.content)), "string.const"}
+		case 2:
+			check = validationCheck{fmt.Sprintf("%s < %d", codePoints, rule.value), "string.min_len"}
+		case 3:
+			check = validationCheck{fmt.Sprintf("%s > %d", codePoints, rule.value), "string.max_len"}
+		case 4:
+			check = validationCheck{fmt.Sprintf("bytes(%s).length < %d", value, rule.value), "string.min_bytes"}
+		case 5:
+			check = validationCheck{fmt.Sprintf("bytes(%s).length > %d", value, rule.value), "string.max_bytes"}
+		case 10:
+			inValues = append(inValues, rule.content)
+			continue
+		case 11:
+			notInValues = append(notInValues, rule.content)
+			continue
+		case 19:
+			check = validationCheck{fmt.Sprintf("%s != %d", codePoints, rule.value), "string.len"}
+		case 20:
+			check = validationCheck{fmt.Sprintf("bytes(%s).length != %d", value, rule.value), "string.len_bytes"}
+		case pgvRule(bufValidate, 26):
+			ignoreEmpty = rule.value != 0
+			continue
+		case bufValidateRule(bufValidate, 34):
+			// Example values do not constrain the field
+			continue
+		default:
+			return nil, false, fmt.Errorf("validate does not support string rule %d", rule.number)
+		}
+		checks = append(checks, check)
+	}
+
+	return append(checks, hashListChecks("string", hash, inValues, notInValues)...), ignoreEmpty, nil
+}
+
+// bytesChecks returns the checks of bytes rules
+func (vg *ValidateGenerator) bytesChecks(rules []ruleField, value string, bufValidate bool) ([]validationCheck, bool, error) {
+	hash := fmt.Sprintf("keccak256(%s)", value)
+
+	var checks []validationCheck
+	var inValues, notInValues [][]byte
+	ignoreEmpty := false
+	for _, rule := range rules {
+		var check validationCheck
+		switch rule.number {
+		case 1:
+			check = validationCheck{fmt.Sprintf("%s != keccak256(%s)", hash, solidityBytesLiteral(rule.content)), "bytes.const"}
+		case 2:
+			check = validationCheck{fmt.Sprintf("%s.length < %d", value, rule.value), "bytes.min_len"}
+		case 3:
+			check = validationCheck{fmt.Sprintf("%s.length > %d", value, rule.value), "bytes.max_len"}
+		case 8:
+			inValues = append(inValues, rule.content)
+			continue
+		case 9:
+			notInValues = append(notInValues, rule.content)
+			continue
+		case 13:
+			check = validationCheck{fmt.Sprintf("%s.length != %d", value, rule.value), "bytes.len"}
+		case 14:
+			if bufValidate {
+				// Example values do not constrain the field
+				continue
+			}
+			ignoreEmpty = rule.value != 0
+			continue
+		default:
+			return nil, false, fmt.Errorf("validate does not support bytes rule %d", rule.number)
+		}
+		checks = append(checks, check)
+	}
+
+	return append(checks, hashListChecks("bytes", hash, inValues, notInValues)...), ignoreEmpty, nil
+}
+
+// hashListChecks returns the checks of the in and not_in rules of strings or bytes, which compare hashes
+func hashListChecks(kindName string, hash string, inValues [][]byte, notInValues [][]byte) []validationCheck {
+	var checks []validationCheck
+	if len(inValues) > 0 {
+		conditions := make([]string, len(inValues))
+		for i, value := range inValues {
+			conditions[i] = fmt.Sprintf("%s != keccak256(%s)", hash, solidityBytesLiteral(value))
+		}
+		checks = append(checks, validationCheck{strings.Join(conditions, " && "), kindName + ".in"})
+	}
+	if len(notInValues) > 0 {
+		conditions := make([]string, len(notInValues))
+		for i, value := range notInValues {
+			conditions[i] = fmt.Sprintf("%s == keccak256(%s)", hash, solidityBytesLiteral(value))
+		}
+		checks = append(checks, validationCheck{strings.Join(conditions, " || "), kindName + ".not_in"})
+	}
+	return checks
+}
+
+// enumChecks returns the checks of enum rules. Enum values are compared by number.
+func (vg *ValidateGenerator) enumChecks(rules []ruleField, value string, bufValidate bool) ([]validationCheck, error) {
+	number := fmt.Sprintf("uint256(%s)", value)
+
+	var checks []validationCheck
+	var inValues, notInValues []*big.Int
+	for _, rule := range rules {
+		if rule.number == 2 {
+			// Solidity enums only hold defined values, and decoders reject the others
+			continue
+		}
+		if bufValidate && rule.number == 5 {
+			// Example values do not constrain the field
+			continue
+		}
+
+		values, err := numericRuleValues(descriptorpb.FieldDescriptorProto_TYPE_ENUM, rule)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range values {
+			if v.Sign() < 0 {
+				return nil, errors.New("enum rules cannot hold negative values")
+			}
+		}
+		switch rule.number {
+		case 1:
+			checks = append(checks, validationCheck{fmt.Sprintf("%s != %s", number, values[0]), "enum.const"})
+		case 3:
+			inValues = append(inValues, values...)
+		case 4:
+			notInValues = append(notInValues, values...)
+		default:
+			return nil, fmt.Errorf("validate does not support enum rule %d", rule.number)
+		}
+	}
+	return append(checks, numericListChecks("enum", number, inValues, notInValues)...), nil
+}
+
+// numericChecks returns the checks of numeric rules.
+// A lower bound above the upper bound makes the range exclusive, accepting values outside of it.
+func (vg *ValidateGenerator) numericChecks(fieldType descriptorpb.FieldDescriptorProto_Type, kindName string, rules []ruleField, value string, bufValidate bool) ([]validationCheck, bool, error) {
+	var checks []validationCheck
+	var lower, upper *validationCheck
+	var lowerValue, upperValue *big.Int
+	var inValues, notInValues []*big.Int
+	ignoreEmpty := false
+	for _, rule := range rules {
+		if rule.number == 8 {
+			// ignore_empty in protoc-gen-validate, example values, which do not constrain the field, in buf.validate
+			if !bufValidate {
+				ignoreEmpty = rule.value != 0
+			}
+			continue
+		}
+
+		values, err := numericRuleValues(fieldType, rule)
+		if err != nil {
+			return nil, false, err
+		}
+		switch rule.number {
+		case 1:
+			checks = append(checks, validationCheck{fmt.Sprintf("%s != %s", value, values[0]), kindName + ".const"})
+		case 2:
+			upper, upperValue = &validationCheck{fmt.Sprintf("%s >= %s", value, values[0]), kindName + ".lt"}, values[0]
+		case 3:
+			upper, upperValue = &validationCheck{fmt.Sprintf("%s > %s", value, values[0]), kindName + ".lte"}, values[0]
+		case 4:
+			lower, lowerValue = &validationCheck{fmt.Sprintf("%s <= %s", value, values[0]), kindName + ".gt"}, values[0]
+		case 5:
+			lower, lowerValue = &validationCheck{fmt.Sprintf("%s < %s", value, values[0]), kindName + ".gte"}, values[0]
+		case 6:
+			inValues = append(inValues, values...)
+		case 7:
+			notInValues = append(notInValues, values...)
+		default:
+			return nil, false, fmt.Errorf("validate does not support %s rule %d", kindName, rule.number)
+		}
+	}
+
+	switch {
+	case lower != nil && upper != nil && lowerValue.Cmp(upperValue) > 0:
+		rule := lower.rule + "_" + upper.rule[strings.LastIndex(upper.rule, ".")+1:] + "_exclusive"
+		checks = append(checks, validationCheck{fmt.Sprintf("%s && %s", lower.condition, upper.condition), rule})
+	default:
+		if lower != nil {
+			checks = append(checks, *lower)
+		}
+		if upper != nil {
+			checks = append(checks, *upper)
+		}
+	}
+	return append(checks, numericListChecks(kindName, value, inValues, notInValues)...), ignoreEmpty, nil
+}
+
+// numericListChecks returns the checks of the in and not_in rules of numbers
+func numericListChecks(kindName string, value string, inValues []*big.Int, notInValues []*big.Int) []validationCheck {
+	var checks []validationCheck
+	if len(inValues) > 0 {
+		checks = append(checks, validationCheck{comparisonList(value, "!=", " && ", inValues), kindName + ".in"})
+	}
+	if len(notInValues) > 0 {
+		checks = append(checks, validationCheck{comparisonList(value, "==", " || ", notInValues), kindName + ".not_in"})
+	}
+	return checks
+}
+
+// comparisonList returns the comparisons of value with each of values, joined with a logical operator
+func comparisonList(value string, operator string, join string, values []*big.Int) string {
+	conditions := make([]string, len(values))
+	for i, v := range values {
+		conditions[i] = fmt.Sprintf("%s %s %s", value, operator, v)
+	}
+	return strings.Join(conditions, join)
+}
@@ -7,10 +7,50 @@ import (
 	"google.golang.org/protobuf/types/descriptorpb"
 )
 
-// checkSyntaxVersion checks that the syntax version is proto3
+// fileSyntax returns the syntax of a file, which is proto2 when it has no syntax declaration
+func fileSyntax(protoFile *descriptorpb.FileDescriptorProto) string {
+	if len(protoFile.GetSyntax()) == 0 {
+		return "proto2"
+	}
+	return protoFile.GetSyntax()
+}
+
+// supportedSyntaxes are the syntaxes of the files the generator accepts
+var supportedSyntaxes = []string{"proto2", "proto3", "editions"}
+
+// isSupportedSyntax checks if files of a syntax are accepted
+func isSupportedSyntax(syntax string) bool {
+	for _, supported := range supportedSyntaxes {
+		if syntax == supported {
+			return true
+		}
+	}
+	return false
+}
+
+// checkSyntaxVersion checks that the syntax version is proto2, proto3 or editions
 func checkSyntaxVersion(syntax string) error {
-	if syntax != "proto3" {
-		return fmt.Errorf("only proto3 is supported, got %s", syntax)
+	if !isSupportedSyntax(syntax) {
+		return fmt.Errorf("only proto2, proto3 and editions are supported, got %s", syntax)
+	}
+	return nil
+}
+
+// checkGroupFields validates that a message and its nested messages have no group fields, nor delimited message fields
+// which are groups in editions files
+func checkGroupFields(descriptor *descriptorpb.DescriptorProto, delimitedFields map[*descriptorpb.FieldDescriptorProto]bool) error {
+	for _, field := range descriptor.GetField() {
+		if field.GetType() == descriptorpb.FieldDescriptorProto_TYPE_GROUP {
+			return fmt.Errorf("field '%s' is a group, groups are not supported, use an embedded message instead", field.GetName())
+		}
+		if delimitedFields[field] {
+			return fmt.Errorf("field '%s' has DELIMITED message encoding, which is not supported, use LENGTH_PREFIXED instead", field.GetName())
+		}
+	}
+	for _, nested := range descriptor.GetNestedType() {
+		if err := checkGroupFields(nested, delimitedFields); err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -49,8 +89,9 @@ func checkFieldNumbers(fields []*descriptorpb.FieldDescriptorProto, strictFieldN
 	return nil
 }
 
-// checkRepeatedNumericFields validates that repeated numeric fields are packed
-func checkRepeatedNumericFields(fields []*descriptorpb.FieldDescriptorProto) error {
+// checkRepeatedNumericFields validates that repeated numeric fields have an encoding the generator supports,
+// packed or, in proto2 files, expanded
+func checkRepeatedNumericFields(fields []*descriptorpb.FieldDescriptorProto, isEncodable func(*descriptorpb.FieldDescriptorProto) bool) error {
 	for _, field := range fields {
 		if field.Label == nil || *field.Label != descriptorpb.FieldDescriptorProto_LABEL_REPEATED {
 			continue
@@ -71,7 +112,7 @@ func checkRepeatedNumericFields(fields []*descriptorpb.FieldDescriptorProto) err
 			descriptorpb.FieldDescriptorProto_TYPE_FLOAT,
 			descriptorpb.FieldDescriptorProto_TYPE_DOUBLE,
 			descriptorpb.FieldDescriptorProto_TYPE_BOOL:
-			if !field.GetOptions().GetPacked() {
+			if !isEncodable(field) {
 				return fmt.Errorf("repeated numeric field '%s' must be packed", field.GetName())
 			}
 		}
@@ -1,20 +1,19 @@
 // SPDX-License-Identifier: Apache-2.0
 pragma solidity ^0.8.19;
 
-import "ProtobufLib.sol";
-import "./all_features.proto.sol";
-import "./top.proto.sol";
+import "@lazyledger/protobuf3-solidity-lib/contracts/ProtobufLib.sol";
+import "./all_features.sol";
 
 contract TestFixture {
     // Functions are not pure so that we can measure gas
 
-    function decode(bytes memory buf) public returns (bool, Message memory) {
-        (bool success, uint64 pos, Message memory instance) = MessageCodec.decode(0, buf, uint64(buf.length));
+    function decode(bytes memory buf) public returns (bool, DefaultPackage.Message memory) {
+        (bool success, uint64 pos, DefaultPackage.Message memory instance) = MessageCodec.decode(0, buf, uint64(buf.length));
 
         return (success, instance);
     }
 
-    // function encode(Message memory instance) public returns (bytes memory) {
+    // function encode(DefaultPackage.Message memory instance) public returns (bytes memory) {
     //     return MessageCodec.encode(instance);
     // }
 }
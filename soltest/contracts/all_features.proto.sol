@@ -1 +0,0 @@
-../../test/pass/all_features/all_features.proto.sol
\ No newline at end of file
@@ -1 +0,0 @@
-../../test/pass/import/bottom.proto.sol
\ No newline at end of file
@@ -1 +0,0 @@
-../../test/pass/import/top.proto.sol
\ No newline at end of file
@@ -1,3 +1,4 @@
+const fs = require("fs");
 const protobuf = require("protobufjs");
 const truffleAssert = require("truffle-assertions");
 
@@ -5,6 +6,16 @@ const TestFixture = artifacts.require("TestFixture");
 
 const AllFeaturesProtoFile = "../test/pass/all_features/all_features.proto";
 
+// Decodes buf, appending the result to the file named by SOLTEST_DECODED if set,
+// so the results of the optimize modes can be compared
+async function decodeCall(instance, buf) {
+  const result = await instance.decode.call(buf);
+  if (process.env.SOLTEST_DECODED) {
+    fs.appendFileSync(process.env.SOLTEST_DECODED, JSON.stringify({ input: buf, result: result }) + "\n");
+  }
+  return result;
+}
+
 contract("TestFixture", async (accounts) => {
   describe("constructor", async () => {
     it("should deploy", async () => {
@@ -65,7 +76,7 @@ contract("TestFixture", async (accounts) => {
         const message = Message.create(messageObj);
         const encoded = Message.encode(message).finish().toString("hex");
 
-        const result = await instance.decode.call("0x" + encoded);
+        const result = await decodeCall(instance, "0x" + encoded);
         const { 0: success, 1: decoded } = result;
         assert.equal(success, true);
         assert.equal(decoded.optional_int32, messageObj.optionalInt32);
@@ -115,7 +126,7 @@ contract("TestFixture", async (accounts) => {
         const message = Message.create(messageObj);
         const encoded = Message.encode(message).finish().toString("hex");
 
-        const result = await instance.decode.call("0x" + encoded);
+        const result = await decodeCall(instance, "0x" + encoded);
         const { 0: success, 1: decoded } = result;
         assert.equal(success, true);
         assert.equal(decoded.optional_uint64, messageObj.optionalUint64);
@@ -135,7 +146,7 @@ contract("TestFixture", async (accounts) => {
 
         const encoded = "20011801";
 
-        const result = await instance.decode.call("0x" + encoded);
+        const result = await decodeCall(instance, "0x" + encoded);
         const { 0: success, 1: decoded } = result;
         assert.equal(success, false);
       });
@@ -149,7 +160,7 @@ contract("TestFixture", async (accounts) => {
 
         const encoded = "20012001";
 
-        const result = await instance.decode.call("0x" + encoded);
+        const result = await decodeCall(instance, "0x" + encoded);
         const { 0: success, 1: decoded } = result;
         assert.equal(success, false);
       });
@@ -164,7 +175,7 @@ contract("TestFixture", async (accounts) => {
 
         const encoded = "18002001";
 
-        const result = await instance.decode.call("0x" + encoded);
+        const result = await decodeCall(instance, "0x" + encoded);
         const { 0: success, 1: decoded } = result;
         assert.equal(success, false);
       });
@@ -179,7 +190,7 @@ contract("TestFixture", async (accounts) => {
 
         const encoded = "18012001deadbeef";
 
-        const result = await instance.decode.call("0x" + encoded);
+        const result = await decodeCall(instance, "0x" + encoded);
         const { 0: success, 1: decoded } = result;
         assert.equal(success, false);
       });
@@ -0,0 +1,74 @@
+const fs = require('fs');
+const path = require('path');
+
+// Test: Check that codecs convert between protobuf and ABI encodings, except for recursive structs
+function testABIConversion() {
+  const solFile = path.join(__dirname, 'abi_conversion/abi_conversion.sol');
+
+  if (!fs.existsSync(solFile)) {
+    console.error('❌ Test file not generated');
+    process.exit(1);
+  }
+
+  const solContent = fs.readFileSync(solFile, 'utf8');
+
+  const codecs = {};
+  for (const codec of solContent.split(/^library /m).slice(1)) {
+    codecs[codec.slice(0, codec.indexOf(' '))] = codec;
+  }
+
+  for (const name of ['Transfer', 'Batch']) {
+    const codec = codecs[`${name}Codec`];
+    if (!codec) {
+      console.error(`❌ ${name}Codec not generated`);
+      process.exit(1);
+    }
+
+    // to_abi decodes exactly one message and ABI encodes the struct
+    const toABI = new RegExp(
+      'function to_abi\\(bytes memory protoBuf\\) internal pure returns \\(bytes memory\\) \\{\\s*' +
+      'bool success;\\s*uint64 pos;\\s*' +
+      `Abi_conversion\\.${name} memory instance;\\s*` +
+      '\\(success, pos, instance\\) = decode\\(0, protoBuf, uint64\\(protoBuf\\.length\\)\\);\\s*' +
+      'if \\(!success \\|\\| pos != protoBuf\\.length\\) \\{\\s*' +
+      'revert Abi_conversion\\.InvalidEncoding\\(\\);\\s*\\}\\s*' +
+      'return abi\\.encode\\(instance\\);'
+    );
+    if (!toABI.test(codec)) {
+      console.error(`❌ ${name}Codec.to_abi does not decode a whole message into abi.encode`);
+      process.exit(1);
+    }
+
+    // from_abi ABI decodes the struct and protobuf encodes it
+    const fromABI = new RegExp(
+      'function from_abi\\(bytes memory abiBuf\\) internal pure returns \\(bytes memory\\) \\{\\s*' +
+      `return encode\\(abi\\.decode\\(abiBuf, \\(Abi_conversion\\.${name}\\)\\)\\);`
+    );
+    if (!fromABI.test(codec)) {
+      console.error(`❌ ${name}Codec.from_abi does not encode the abi.decode result`);
+      process.exit(1);
+    }
+    if (!/function encode\(Abi_conversion\.\w+ memory instance\) internal pure returns \(bytes memory\)/.test(codec)) {
+      console.error(`❌ ${name}Codec has no encode overload allocating its buffer`);
+      process.exit(1);
+    }
+  }
+
+  // Structs reaching a cycle of structs get no ABI conversion, but are still encoded and decoded
+  for (const name of ['Node', 'Tree']) {
+    const codec = codecs[`${name}Codec`];
+    if (!codec || !codec.includes('function decode(') || !codec.includes('function encode(')) {
+      console.error(`❌ ${name}Codec is not generated`);
+      process.exit(1);
+    }
+    if (codec.includes('function to_abi(') || codec.includes('function from_abi(')) {
+      console.error(`❌ ${name}Codec converts to or from the ABI encoding although its struct reaches a cycle`);
+      process.exit(1);
+    }
+  }
+
+  console.log('✅ ABI conversion test passed');
+}
+
+// Run the test
+testABIConversion();
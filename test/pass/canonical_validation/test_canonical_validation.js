@@ -0,0 +1,78 @@
+const fs = require('fs');
+const path = require('path');
+
+// Test: Check that every codec gets an is_canonical validator rejecting non-canonical encodings
+function testCanonicalValidation() {
+  const solFile = path.join(__dirname, 'canonical_validation/canonical_validation.sol');
+
+  if (!fs.existsSync(solFile)) {
+    console.error('❌ Test file not generated');
+    process.exit(1);
+  }
+
+  const solContent = fs.readFileSync(solFile, 'utf8');
+
+  // Every codec, including the map entry wrappers, must have a validator
+  const validators = solContent.match(/function is_canonical\(bytes memory buf\) internal pure returns \(bool\) \{/g) || [];
+  if (validators.length !== 4) {
+    console.error(`❌ Expected 4 is_canonical validators, found ${validators.length}`);
+    process.exit(1);
+  }
+
+  // Varints must be minimal and fields in ascending order
+  if (!/function is_minimal_varint\(uint64 pos, bytes memory buf\) internal pure returns \(bool\)/.test(solContent)) {
+    console.error('❌ is_minimal_varint helper not generated');
+    process.exit(1);
+  }
+  if (!solContent.includes('// Fields must be in ascending order')) {
+    console.error('❌ Field order not checked');
+    process.exit(1);
+  }
+
+  // Default values and empty packed arrays must be rejected
+  if (!solContent.includes('// Default values must be omitted')) {
+    console.error('❌ Default values not rejected');
+    process.exit(1);
+  }
+  if (!solContent.includes('// Empty packed arrays must be omitted')) {
+    console.error('❌ Empty packed arrays not rejected');
+    process.exit(1);
+  }
+
+  // Map entries must be canonical and sorted by key
+  if (!/BalancesEntryCodec\.check_canonical\(new_pos, buf, size, depth \+ 1\)/.test(solContent)) {
+    console.error('❌ Map entries not checked');
+    process.exit(1);
+  }
+  if (!/is_bytes_less_than\(buf, previous_key_pos, previous_key_len, key_pos, key_len\)/.test(solContent)) {
+    console.error('❌ String map keys not checked for ascending order');
+    process.exit(1);
+  }
+  if (!/if \(has_previous_key && previous_key >= key\)/.test(solContent)) {
+    console.error('❌ Integer map keys not checked for ascending order');
+    process.exit(1);
+  }
+
+  // A malformed map key must be rejected instead of compared
+  const keyDecodes = [
+    /\(success, key_pos, key_len\) = ProtobufLib\.decode_length_delimited\(new_pos \+ 1, buf\);\s*if \(!success\) \{\s*return \(false, pos\);/,
+    /\(success, key_end_pos, key\) = ProtobufLib\.decode_uint64\(new_pos \+ 1, buf\);\s*if \(!success\) \{\s*return \(false, pos\);/,
+  ];
+  for (const keyDecode of keyDecodes) {
+    if (!keyDecode.test(solContent)) {
+      console.error(`❌ Map key decoding result not checked: ${keyDecode}`);
+      process.exit(1);
+    }
+  }
+
+  // Embedded messages must be canonical themselves
+  if (!/EntryCodec\.check_canonical\(new_pos, buf, size, depth \+ 1\)/.test(solContent)) {
+    console.error('❌ Embedded message not checked');
+    process.exit(1);
+  }
+
+  console.log('✅ Canonical validation test passed');
+}
+
+// Run the test
+testCanonicalValidation();
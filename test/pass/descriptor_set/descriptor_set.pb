@@ -0,0 +1,6 @@
+
+p
+descriptor_set.protodescriptor_set"@
+Transfer
+	recipient (	R	recipient
+amount (Ramountbproto3
\ No newline at end of file
@@ -0,0 +1,34 @@
+const fs = require('fs');
+const path = require('path');
+
+// Test: Check that the generate command writes the files of a descriptor set without protoc, with its options applied
+function testDescriptorSet() {
+  const solFile = path.join(__dirname, 'descriptor_set/descriptor_set.sol');
+
+  if (!fs.existsSync(solFile)) {
+    console.error('❌ Test file not generated');
+    process.exit(1);
+  }
+
+  const solContent = fs.readFileSync(solFile, 'utf8');
+
+  if (!solContent.includes('// SPDX-License-Identifier: Apache-2.0')) {
+    console.error('❌ --opt parameters were not applied');
+    process.exit(1);
+  }
+
+  if (!/struct Transfer \{\s*string recipient;\s*uint32 amount;/.test(solContent)) {
+    console.error('❌ Transfer struct not generated');
+    process.exit(1);
+  }
+
+  // generate=all produces both directions
+  if (!solContent.includes('function decode(') || !solContent.includes('function encode(')) {
+    console.error('❌ Codec functions not generated');
+    process.exit(1);
+  }
+
+  console.log('✅ Descriptor set test passed');
+}
+
+testDescriptorSet();
@@ -0,0 +1,52 @@
+const fs = require('fs');
+const path = require('path');
+
+// Test: Check that fields of editions files follow their field_presence, repeated_field_encoding and enum_type features
+function testEditionsFeatures() {
+  const solFile = path.join(__dirname, 'editions_features/editions_features.sol');
+
+  if (!fs.existsSync(solFile)) {
+    console.error('❌ Test file not generated');
+    process.exit(1);
+  }
+
+  const solContent = fs.readFileSync(solFile, 'utf8');
+
+  // Singular fields have explicit presence by default, IMPLICIT fields do not track it
+  if (!/struct Item \{[^}]*bool _has_id;\s*bool _has_name;\s*bool _has_status;\s*\}/.test(solContent)) {
+    console.error('❌ Item struct does not track the presence of its explicit fields');
+    process.exit(1);
+  }
+  if (solContent.includes('_has_count') || solContent.includes('_has_values')) {
+    console.error('❌ Implicit and repeated fields must not track their presence');
+    process.exit(1);
+  }
+
+  // LEGACY_REQUIRED fields fail decoding when missing
+  if (!/\/\/ Required fields must be present\s*if \(!instance\._has_id\) \{/.test(solContent)) {
+    console.error('❌ Decoder does not enforce LEGACY_REQUIRED fields');
+    process.exit(1);
+  }
+
+  // Explicit fields are encoded when set, implicit fields when not default
+  if (!solContent.includes('if (instance._has_name) {') || !solContent.includes('if (instance.count != 0) {')) {
+    console.error('❌ Encoder does not follow field presence');
+    process.exit(1);
+  }
+
+  // Repeated numeric fields are packed by default, without a packed option
+  if (!solContent.includes('// Empty packed arrays must be omitted instead of encoded')) {
+    console.error('❌ Repeated numeric fields are not decoded as packed');
+    process.exit(1);
+  }
+
+  // Closed enums are supported, open ones fail generation
+  if (!solContent.includes('enum Status { STATUS_UNSPECIFIED, STATUS_ACTIVE }')) {
+    console.error('❌ Closed enum not generated');
+    process.exit(1);
+  }
+
+  console.log('✅ Editions features test passed');
+}
+
+testEditionsFeatures();
@@ -0,0 +1,60 @@
+const fs = require('fs');
+const path = require('path');
+
+// Test: Check that EIP-712 type hashes and type definitions are generated
+function testEip712TypedData() {
+  const solFile = path.join(__dirname, 'eip712_typed_data/eip712_typed_data.sol');
+  const typesFile = path.join(__dirname, 'eip712_typed_data/eip712_typed_data.eip712.json');
+
+  if (!fs.existsSync(solFile) || !fs.existsSync(typesFile)) {
+    console.error('❌ Test files not generated');
+    process.exit(1);
+  }
+
+  const solContent = fs.readFileSync(solFile, 'utf8');
+  const types = JSON.parse(fs.readFileSync(typesFile, 'utf8'));
+
+  // Referenced types follow the primary type in name order
+  const orderType = 'Order(uint64 nonce,uint8 side,bytes maker,Leg[] legs,FeesEntry[] fees,int64 expiry,bytes parent)' +
+    'FeesEntry(string key,uint64 value)Leg(string asset,uint64 amount)';
+  if (!solContent.includes(`bytes32 internal constant TYPEHASH = keccak256("${orderType}");`)) {
+    console.error('❌ Order TYPEHASH does not follow the EIP-712 type encoding');
+    process.exit(1);
+  }
+
+  if (!/function hash_struct\(Eip712_typed_data\.Order memory instance\) internal pure returns \(bytes32\)/.test(solContent)) {
+    console.error('❌ Order hash_struct not generated');
+    process.exit(1);
+  }
+
+  // Nested messages are hashed through their codecs, recursive fields as bytes
+  if (!/hashes\[i\] = LegCodec\.hash_struct\(instance\.legs\[i\]\);/.test(solContent)) {
+    console.error('❌ Order.legs elements are not hashed with hash_struct');
+    process.exit(1);
+  }
+  if (!/= keccak256\(instance\.parent\);/.test(solContent)) {
+    console.error('❌ Order.parent is not hashed as bytes');
+    process.exit(1);
+  }
+
+  // Type definitions match the type hashes
+  const expectedTypes = {
+    FeesEntry: [{ name: 'key', type: 'string' }, { name: 'value', type: 'uint64' }],
+    Leg: [{ name: 'asset', type: 'string' }, { name: 'amount', type: 'uint64' }],
+  };
+  for (const [name, members] of Object.entries(expectedTypes)) {
+    if (JSON.stringify(types[name]) !== JSON.stringify(members)) {
+      console.error(`❌ Type definition of ${name} does not match`);
+      process.exit(1);
+    }
+  }
+  if (!types.Order || types.Order.length !== 7) {
+    console.error('❌ Type definition of Order is missing members');
+    process.exit(1);
+  }
+
+  console.log('✅ EIP-712 typed data properly generated');
+}
+
+// Run the test
+testEip712TypedData();
@@ -0,0 +1,37 @@
+const fs = require('fs');
+const path = require('path');
+
+// Test: Check that allow_empty_packed_arrays=true only relaxes decoders, encoders still omit empty packed arrays
+function testEmptyPackedArrays() {
+  const solFile = path.join(__dirname, 'empty_packed_arrays/empty_packed_arrays.sol');
+
+  if (!fs.existsSync(solFile)) {
+    console.error('❌ Test file not generated');
+    process.exit(1);
+  }
+
+  const solContent = fs.readFileSync(solFile, 'utf8');
+
+  // Decoders accept zero-length packed payloads
+  if (solContent.includes('// Empty packed arrays must be omitted instead of encoded')) {
+    console.error('❌ Decoder rejects empty packed arrays');
+    process.exit(1);
+  }
+
+  // Encoders and encoded_size skip empty arrays, so the output stays canonical
+  for (const field of ['values', 'deltas']) {
+    const guard = `if (instance.${field}.length > 0) {`;
+    if (solContent.split(guard).length - 1 !== 2) {
+      console.error(`❌ Encoder or encoded_size does not omit an empty ${field} array`);
+      process.exit(1);
+    }
+  }
+  if (/^\s*\{\s*$/m.test(solContent)) {
+    console.error('❌ Packed array encoded without a length guard');
+    process.exit(1);
+  }
+
+  console.log('✅ Empty packed arrays test passed');
+}
+
+testEmptyPackedArrays();
@@ -0,0 +1,56 @@
+const fs = require('fs');
+const path = require('path');
+
+// Test: Check that (sol.uint256) and (sol.int256) make bytes and string fields 256 bit integers
+function testLargeIntegers() {
+  const solFile = path.join(__dirname, 'large_integers/large_integers.sol');
+
+  if (!fs.existsSync(solFile)) {
+    console.error('❌ Test file not generated');
+    process.exit(1);
+  }
+
+  const solContent = fs.readFileSync(solFile, 'utf8');
+
+  if (!/struct Balance \{\s*uint256 amount;\s*int256 delta;\s*uint256 supply;\s*int256 pnl;\s*\}/.test(solContent)) {
+    console.error('❌ Balance struct does not use 256 bit integers');
+    process.exit(1);
+  }
+
+  // Bytes fields hold exactly 32 bytes
+  if (!solContent.includes('uint256 value = uint256(word);') || !solContent.includes('int256 value = int256(uint256(word));')) {
+    console.error('❌ Bytes fields are not decoded as 32 byte big-endian integers');
+    process.exit(1);
+  }
+
+  // String fields hold decimal integers, parsed with overflow checks
+  if (!/function decode_decimal_uint256\(/.test(solContent) || !/function decode_decimal_int256\(/.test(solContent)) {
+    console.error('❌ Decimal parsing helpers not generated');
+    process.exit(1);
+  }
+
+  if (!solContent.includes('(valid, value) = Large_integers.decode_decimal_uint256(buf, new_pos, length);') ||
+      !solContent.includes('(valid, value) = Large_integers.decode_decimal_int256(buf, new_pos, length);')) {
+    console.error('❌ String fields are not decoded as decimal integers');
+    process.exit(1);
+  }
+
+  if (!solContent.includes('pos = ProtobufLib.encode_bytes(pos, buf, abi.encodePacked(instance.amount));') ||
+      !solContent.includes('pos = ProtobufLib.encode_bytes(pos, buf, Large_integers.encode_decimal_int256(instance.pnl));')) {
+    console.error('❌ Encoder does not convert integers back to bytes and decimal strings');
+    process.exit(1);
+  }
+
+  // Encoders allocate exactly the encoded size: 32 byte values take a length byte, decimal strings their digits
+  if (!solContent.includes('size += 1 + 33;') ||
+      !solContent.includes('size += 1 + Large_integers.bytes_size(Large_integers.encode_decimal_int256(instance.pnl).length);') ||
+      (solContent.match(/bytes memory buf = new bytes\(encoded_size\(instance\)\);/g) || []).length !== 2) {
+    console.error('❌ Encoders of messages with large integers do not size their buffer exactly');
+    process.exit(1);
+  }
+
+  console.log('✅ Large integer types properly generated');
+}
+
+// Run the test
+testLargeIntegers();
@@ -0,0 +1,47 @@
+const fs = require('fs');
+const path = require('path');
+
+// Test: Check that equals compares every field of a message
+function testMessageEquality() {
+  const solFile = path.join(__dirname, 'message_equality/message_equality.sol');
+
+  if (!fs.existsSync(solFile)) {
+    console.error('❌ Test file not generated');
+    process.exit(1);
+  }
+
+  const solContent = fs.readFileSync(solFile, 'utf8');
+
+  if (!/function equals\(Message_equality\.Shape memory a, Message_equality\.Shape memory b\) internal pure returns \(bool\)/.test(solContent)) {
+    console.error('❌ Shape equals not generated');
+    process.exit(1);
+  }
+
+  // Strings and bytes are compared by hash
+  if (!/keccak256\(bytes\(a\.name\)\) != keccak256\(bytes\(b\.name\)\)/.test(solContent) ||
+      !/keccak256\(a\.data\) != keccak256\(b\.data\)/.test(solContent)) {
+    console.error('❌ Strings and bytes are not compared by hash');
+    process.exit(1);
+  }
+
+  // Nested messages, arrays and map entries recurse into their codecs
+  if (!/!PointCodec\.equals\(a\.points\[i\], b\.points\[i\]\)/.test(solContent)) {
+    console.error('❌ Shape.points elements are not compared with equals');
+    process.exit(1);
+  }
+  if (!/!TagsEntryCodec\.equals\(a\.tags\[i\], b\.tags\[i\]\)/.test(solContent)) {
+    console.error('❌ Shape.tags entries are not compared with equals');
+    process.exit(1);
+  }
+
+  // Oneof members are compared like other fields
+  if (!/!PointCodec\.equals\(a\.center, b\.center\)/.test(solContent) || !/a\.anchor != b\.anchor/.test(solContent)) {
+    console.error('❌ Oneof members are not compared');
+    process.exit(1);
+  }
+
+  console.log('✅ Message equality properly generated');
+}
+
+// Run the test
+testMessageEquality();
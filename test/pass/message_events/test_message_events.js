@@ -0,0 +1,41 @@
+const fs = require('fs');
+const path = require('path');
+
+// Test: Check that events=true generates events with the scalar fields of messages
+function testMessageEvents() {
+  const solFile = path.join(__dirname, 'message_events/message_events.sol');
+
+  if (!fs.existsSync(solFile)) {
+    console.error('❌ Test file not generated');
+    process.exit(1);
+  }
+
+  const solContent = fs.readFileSync(solFile, 'utf8');
+
+  // Scalar fields are parameters, fields marked with (sol.indexed) are indexed
+  if (!solContent.includes('event TransferDecoded(uint64 indexed id, bytes indexed sender, uint64 amount, Message_events.Status status, string memo);')) {
+    console.error('❌ TransferDecoded event does not match the scalar fields of Transfer');
+    process.exit(1);
+  }
+
+  if (!/function emit_transfer\(Message_events\.Transfer memory instance\) internal \{\s*emit TransferDecoded\(instance\.id, instance\.sender, instance\.amount, instance\.status, instance\.memo\);/.test(solContent)) {
+    console.error('❌ emit_transfer helper not generated');
+    process.exit(1);
+  }
+
+  if (!/event AccountDecoded\(string owner\);/.test(solContent) || !/function emit_account\(/.test(solContent)) {
+    console.error('❌ Account event not generated');
+    process.exit(1);
+  }
+
+  // The options definition is not imported by the generated code
+  if (/import ".*options\.sol";/.test(solContent)) {
+    console.error('❌ solidity/options.proto should not be imported');
+    process.exit(1);
+  }
+
+  console.log('✅ Message events properly generated');
+}
+
+// Run the test
+testMessageEvents();
@@ -0,0 +1,52 @@
+const fs = require('fs');
+const path = require('path');
+
+// Test: Check that decoders and canonical checks thread the nesting depth and enforce the default max_depth
+function testNestedDepth() {
+  const solFile = path.join(__dirname, 'nested_depth/nested_depth.sol');
+
+  if (!fs.existsSync(solFile)) {
+    console.error('❌ Test file not generated');
+    process.exit(1);
+  }
+
+  const solContent = fs.readFileSync(solFile, 'utf8');
+
+  // The depth error must be declared once in the main library
+  if (!/error MaxDepthExceeded\(uint64 depth\);/.test(solContent)) {
+    console.error('❌ MaxDepthExceeded error not declared');
+    process.exit(1);
+  }
+
+  // Every decoder and canonical check must enforce the default depth limit
+  const checks = solContent.match(/if \(depth > 16\) \{/g) || [];
+  if (checks.length !== 6) {
+    console.error(`❌ Expected 6 depth checks, found ${checks.length}`);
+    process.exit(1);
+  }
+
+  // Embedded messages must be decoded one level deeper
+  if (!/BranchCodec\.decode\(new_pos, buf, size, depth \+ 1\)/.test(solContent)) {
+    console.error('❌ Root does not pass the depth to BranchCodec');
+    process.exit(1);
+  }
+  if (!/LeafCodec\.decode\(new_pos, buf, size, depth \+ 1\)/.test(solContent)) {
+    console.error('❌ Branch does not pass the depth to LeafCodec');
+    process.exit(1);
+  }
+
+  // Embedded messages must be checked for canonical encoding one level deeper
+  if (!/BranchCodec\.check_canonical\(new_pos, buf, size, depth \+ 1\)/.test(solContent)) {
+    console.error('❌ Root canonical check does not pass the depth to BranchCodec');
+    process.exit(1);
+  }
+  if (!/LeafCodec\.check_canonical\(new_pos, buf, size, depth \+ 1\)/.test(solContent)) {
+    console.error('❌ Branch canonical check does not pass the depth to LeafCodec');
+    process.exit(1);
+  }
+
+  console.log('✅ Nested decoding depth properly limited');
+}
+
+// Run the test
+testNestedDepth();
@@ -0,0 +1,34 @@
+const fs = require('fs');
+const path = require('path');
+
+// Test: Check that encoders and encoded_size omit absent nested messages with a test their structs support
+function testNestedMessage() {
+  const solFile = path.join(__dirname, 'nested_message.sol');
+
+  if (!fs.existsSync(solFile)) {
+    console.error('❌ Test file not generated');
+    process.exit(1);
+  }
+
+  const solContent = fs.readFileSync(solFile, 'utf8');
+
+  // Message structs have no value member
+  if (solContent.includes('.value.length')) {
+    console.error('❌ Nested message tested through a value member');
+    process.exit(1);
+  }
+
+  // A nested message is absent when it has nothing to encode
+  if (!/function encoded_size\(DefaultPackage\.Message memory instance\) internal pure returns \(uint64\) \{\s*uint64 size = 0;\s*if \(OtherMessageCodec\.encoded_size\(instance\.optional_message\) > 0\) \{\s*size \+= 1 \+ 1 \+ OtherMessageCodec\.encoded_size\(instance\.optional_message\);/.test(solContent)) {
+    console.error('❌ encoded_size does not size the nested message');
+    process.exit(1);
+  }
+  if (!/function encode_1\(uint64 pos, bytes memory buf, DefaultPackage\.Message memory instance\) internal pure returns \(uint64\) \{\s*if \(OtherMessageCodec\.encoded_size\(instance\.optional_message\) > 0\) \{/.test(solContent)) {
+    console.error('❌ Encoder does not omit an absent nested message');
+    process.exit(1);
+  }
+
+  console.log('✅ Nested message test passed');
+}
+
+testNestedMessage();
@@ -0,0 +1,81 @@
+const fs = require('fs');
+const path = require('path');
+
+// Test: Check that proto2 fields track their presence, keep their default values and are required when declared so
+function testProto2Fields() {
+  const solFile = path.join(__dirname, 'proto2_fields/proto2_fields.sol');
+
+  if (!fs.existsSync(solFile)) {
+    console.error('❌ Test file not generated');
+    process.exit(1);
+  }
+
+  const solContent = fs.readFileSync(solFile, 'utf8');
+
+  // Singular fields get a presence member, repeated fields do not
+  if (!/struct Order \{[^}]*bool _has_id;\s*bool _has_quantity;\s*bool _has_active;\s*bool _has_memo;\s*bool _has_tag;\s*bool _has_account;\s*\}/.test(solContent)) {
+    console.error('❌ Order struct does not track the presence of its singular fields');
+    process.exit(1);
+  }
+  if (solContent.includes('_has_fills')) {
+    console.error('❌ Repeated fields must not track their presence');
+    process.exit(1);
+  }
+
+  if (!solContent.includes('instance._has_quantity = true;')) {
+    console.error('❌ Decoder does not record field presence');
+    process.exit(1);
+  }
+
+  // Default values are assigned before decoding
+  const defaults = [
+    'instance.nonce = 1;',
+    'instance.quantity = -10;',
+    'instance.active = true;',
+    'instance.memo = "none";',
+    'instance.tag = hex"0102";',
+  ];
+  for (const assignment of defaults) {
+    if (!solContent.includes(assignment)) {
+      console.error(`❌ Default value not applied: ${assignment}`);
+      process.exit(1);
+    }
+  }
+
+  // Required fields fail decoding when missing
+  if (!/\/\/ Required fields must be present\s*if \(!instance\._has_owner\) \{/.test(solContent) ||
+      !/\/\/ Required fields must be present\s*if \(!instance\._has_id\) \{/.test(solContent)) {
+    console.error('❌ Decoder does not enforce required fields');
+    process.exit(1);
+  }
+
+  // Set fields are encoded even when they hold their default value
+  if (!solContent.includes('if (instance._has_quantity) {') || solContent.includes('if (instance.quantity != 0) {')) {
+    console.error('❌ Encoder does not write fields by presence');
+    process.exit(1);
+  }
+
+  // Encoders revert instead of writing a message missing a required field
+  if (!/function encode_1\(uint64 pos, bytes memory buf, Proto2_fields\.Order memory instance\) internal pure returns \(uint64\) \{\s*if \(!instance\._has_id\) \{\s*revert Proto2_fields\.MissingRequiredField\(1\);/.test(solContent) ||
+      !solContent.includes('error MissingRequiredField(uint64 field_number);')) {
+    console.error('❌ Encoder does not enforce required fields');
+    process.exit(1);
+  }
+
+  // Unpacked repeated numeric fields use one key per element
+  if (!/encode_key\(8, ProtobufLib\.WireType\.Varint, pos, buf\);\s*\/\/ Encode element\s*pos = ProtobufLib\.encode_sint64\(pos, buf, instance\.deltas\[i\]\);/.test(solContent) ||
+      !/values\[instance\.deltas\.length\] = value;/.test(solContent) ||
+      !solContent.includes('field_number == previous_field_number && field_number != 8')) {
+    console.error('❌ Unpacked repeated fields are not encoded and decoded one element at a time');
+    process.exit(1);
+  }
+
+  if (!solContent.includes('if (a._has_memo != b._has_memo) {')) {
+    console.error('❌ equals does not compare presence');
+    process.exit(1);
+  }
+
+  console.log('✅ Proto2 fields test passed');
+}
+
+testProto2Fields();
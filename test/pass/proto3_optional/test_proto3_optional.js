@@ -0,0 +1,35 @@
+const fs = require('fs');
+const path = require('path');
+
+// Test: Check that proto3 optional fields, which protoc only passes to plugins declaring support for them,
+// track their presence like proto2 fields
+function testProto3Optional() {
+  const solFile = path.join(__dirname, 'proto3_optional/proto3_optional.sol');
+
+  if (!fs.existsSync(solFile)) {
+    console.error('❌ Test file not generated');
+    process.exit(1);
+  }
+
+  const solContent = fs.readFileSync(solFile, 'utf8');
+
+  // Optional fields get a presence member, other fields do not
+  if (!/struct Message \{[^}]*bool _has_limit;\s*bool _has_label;\s*\}/.test(solContent)) {
+    console.error('❌ Message struct does not track the presence of its optional fields');
+    process.exit(1);
+  }
+  if (solContent.includes('_has_count')) {
+    console.error('❌ Fields without optional must not track their presence');
+    process.exit(1);
+  }
+
+  // Optional fields are encoded when set, even to their default value
+  if (!solContent.includes('if (instance._has_limit) {') || !solContent.includes('if (instance.count != 0) {')) {
+    console.error('❌ Encoder does not follow field presence');
+    process.exit(1);
+  }
+
+  console.log('✅ Proto3 optional test passed');
+}
+
+testProto3Optional();
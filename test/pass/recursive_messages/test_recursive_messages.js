@@ -0,0 +1,65 @@
+const fs = require('fs');
+const path = require('path');
+
+// Test: Check that recursive messages produce structs Solidity accepts
+function testRecursiveMessages() {
+  const solFile = path.join(__dirname, 'recursive_messages/recursive_messages.sol');
+
+  if (!fs.existsSync(solFile)) {
+    console.error('❌ Test file not generated');
+    process.exit(1);
+  }
+
+  const solContent = fs.readFileSync(solFile, 'utf8');
+
+  // Self-references in dynamic arrays are kept as struct arrays
+  if (!/Recursive_messages\.Node\[\] children;/.test(solContent)) {
+    console.error('❌ Node.children is not an array of Node');
+    process.exit(1);
+  }
+
+  // Direct and mutual self-references are held as encoded bytes
+  if (!/struct List \{[^}]*bytes next;/.test(solContent)) {
+    console.error('❌ List.next is not held as bytes');
+    process.exit(1);
+  }
+  if (!/struct Even \{[^}]*bytes next;/.test(solContent) || !/struct Odd \{[^}]*bytes next;/.test(solContent)) {
+    console.error('❌ Even.next and Odd.next are not held as bytes');
+    process.exit(1);
+  }
+
+  // Fields outside the cycle keep their struct type
+  if (!/struct Odd \{[^}]*Recursive_messages\.Leaf leaf;/.test(solContent)) {
+    console.error('❌ Odd.leaf should not be held as bytes');
+    process.exit(1);
+  }
+
+  // Repeated children may share a field number
+  if (!/field_number == previous_field_number && field_number != 2/.test(solContent)) {
+    console.error('❌ Repeated children are rejected by the field order check');
+    process.exit(1);
+  }
+
+  // The ABI coder rejects recursive types, so structs reaching a cycle of structs get no ABI conversion
+  const codecs = {};
+  for (const codec of solContent.split(/^library /m).slice(1)) {
+    codecs[codec.slice(0, codec.indexOf(' '))] = codec;
+  }
+  for (const name of ['NodeCodec', 'ForestCodec']) {
+    if (!codecs[name] || codecs[name].includes('function to_abi(')) {
+      console.error(`❌ ${name} has to_abi although its struct reaches a cycle`);
+      process.exit(1);
+    }
+  }
+  for (const name of ['ListCodec', 'EvenCodec', 'OddCodec', 'LeafCodec']) {
+    if (!codecs[name] || !codecs[name].includes('function to_abi(')) {
+      console.error(`❌ ${name} has no to_abi`);
+      process.exit(1);
+    }
+  }
+
+  console.log('✅ Recursive messages properly generated');
+}
+
+// Run the test
+testRecursiveMessages();
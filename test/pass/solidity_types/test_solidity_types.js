@@ -0,0 +1,53 @@
+const fs = require('fs');
+const path = require('path');
+
+// Test: Check that (sol.type) and (sol.fixed_size) map string and bytes fields to other Solidity types
+function testSolidityTypes() {
+  const solFile = path.join(__dirname, 'solidity_types/solidity_types.sol');
+
+  if (!fs.existsSync(solFile)) {
+    console.error('❌ Test file not generated');
+    process.exit(1);
+  }
+
+  const solContent = fs.readFileSync(solFile, 'utf8');
+
+  if (!/struct Order \{\s*address maker;\s*bytes32 order_hash;\s*bytes4 salt;\s*bytes payload;\s*bytes signature;\s*uint64 amount;\s*\}/.test(solContent)) {
+    console.error('❌ Order struct does not use the overridden types');
+    process.exit(1);
+  }
+
+  // Fixed size values must have exactly their length
+  if (!solContent.includes('if (length != 20) {') || !solContent.includes('address value = address(bytes20(word));')) {
+    console.error('❌ address decoder does not check the length of the value');
+    process.exit(1);
+  }
+
+  if (!solContent.includes('if (length != 32) {') || !solContent.includes('if (length != 4) {')) {
+    console.error('❌ bytesN decoders do not check the length of the value');
+    process.exit(1);
+  }
+
+  // The string field is copied as bytes
+  if (/instance\.payload = string\(/.test(solContent)) {
+    console.error('❌ payload should not be converted to a string');
+    process.exit(1);
+  }
+
+  if (!solContent.includes('pos = ProtobufLib.encode_bytes(pos, buf, abi.encodePacked(instance.maker));') ||
+      !solContent.includes('pos = ProtobufLib.encode_bytes(pos, buf, instance.payload);')) {
+    console.error('❌ Encoder does not encode the overridden types as bytes');
+    process.exit(1);
+  }
+
+  // Zero-filled values are not empty, so even strict decoders accept them
+  if (!/bytes4 value = bytes4\(word\);\s*instance\.salt = value;/.test(solContent)) {
+    console.error('❌ Decoder rejects zero-filled fixed size values');
+    process.exit(1);
+  }
+
+  console.log('✅ Solidity type overrides properly generated');
+}
+
+// Run the test
+testSolidityTypes();
@@ -0,0 +1,53 @@
+const fs = require('fs');
+const path = require('path');
+
+// Test: Check that code generated for solc 0.7 uses no syntax introduced in 0.8
+function testSolidityVersion07() {
+  const solFile = path.join(__dirname, 'solidity_version_07/solidity_version_07.sol');
+
+  if (!fs.existsSync(solFile)) {
+    console.error('❌ Test file not generated');
+    process.exit(1);
+  }
+
+  const solContent = fs.readFileSync(solFile, 'utf8');
+
+  // The pragma keeps the specifier, and ABI coder v2 must be enabled explicitly before 0.8.0
+  if (!/^pragma solidity \^0\.7;\npragma experimental ABIEncoderV2;$/m.test(solContent)) {
+    console.error('❌ Missing the ^0.7 version pragma followed by the ABIEncoderV2 pragma');
+    process.exit(1);
+  }
+
+  const unsupported = [
+    [/\bunchecked\s*\{/, 'unchecked blocks (0.8.0)'],
+    [/^\s*error \w+\(/m, 'custom error declarations (0.8.4)'],
+    [/\brevert \w+(\.\w+)*\(/, 'reverts with custom errors (0.8.4)'],
+    [/\bbytes\.concat\(/, 'bytes.concat (0.8.4)'],
+    [/\bstring\.concat\(/, 'string.concat (0.8.12)'],
+    [/\babi\.encodeCall\(/, 'abi.encodeCall (0.8.11)'],
+    [/\bblock\.chainid\b/, 'block.chainid (0.8.0)'],
+    [/\bmcopy\(/, 'mcopy (0.8.24)'],
+    [/"memory-safe"/, 'memory-safe assembly (0.8.13)'],
+  ];
+  for (const [pattern, feature] of unsupported) {
+    if (pattern.test(solContent)) {
+      console.error(`❌ Generated code uses ${feature}`);
+      process.exit(1);
+    }
+  }
+
+  // The 0.7 fallbacks are used instead
+  if (!solContent.includes('revert("MaxDepthExceeded");') || !solContent.includes('revert("InvalidEncoding");')) {
+    console.error('❌ Decoders do not revert with strings');
+    process.exit(1);
+  }
+  if (!solContent.includes('instance._unknown = abi.encodePacked(instance._unknown, raw);')) {
+    console.error('❌ Preserved unknown fields are not appended with abi.encodePacked');
+    process.exit(1);
+  }
+
+  console.log('✅ Solidity 0.7 test passed');
+}
+
+// Run the test
+testSolidityVersion07();
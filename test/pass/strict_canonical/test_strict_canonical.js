@@ -0,0 +1,80 @@
+const fs = require('fs');
+const path = require('path');
+
+// Test: Check that strict_canonical decoders reject non-minimal varints and encoded default values
+function testStrictCanonical() {
+  const solFile = path.join(__dirname, 'strict_canonical/strict_canonical.sol');
+
+  if (!fs.existsSync(solFile)) {
+    console.error('❌ Test file not generated');
+    process.exit(1);
+  }
+
+  const solContent = fs.readFileSync(solFile, 'utf8');
+
+  if (!/function is_minimal_varint\(uint64 pos, bytes memory buf\) internal pure returns \(bool\)/.test(solContent)) {
+    console.error('❌ is_minimal_varint helper not generated');
+    process.exit(1);
+  }
+
+  // Field keys must be minimal varints
+  if (!/if \(!Strict_canonical\.is_minimal_varint\(pos, buf\)\) \{\s*return \(false, pos, instance\);\s*\}\s*bool success;\s*uint64 field_number;/.test(solContent)) {
+    console.error('❌ Decoder does not reject non-minimal field keys');
+    process.exit(1);
+  }
+
+  // Split the decoder into its per-field branches
+  const start = solContent.indexOf('function decode_field(');
+  const end = solContent.indexOf('\n\tfunction ', start);
+  if (start === -1 || end === -1) {
+    console.error('❌ decode_field not generated');
+    process.exit(1);
+  }
+  const branches = {};
+  solContent.slice(start, end).split(/if \(field_number == (\d+)\) \{/).forEach((body, i, parts) => {
+    if (i % 2 === 1) {
+      branches[body] = parts[i + 1];
+    }
+  });
+
+  // Every field is decoded from minimal varints, except the fixed32 value itself
+  for (const number of ['1', '2', '3', '4', '5', '7', '8']) {
+    if (!branches[number] || !branches[number].includes('return (false, pos); // Non-minimal varint')) {
+      console.error(`❌ Field ${number} accepts non-minimal varints`);
+      process.exit(1);
+    }
+  }
+
+  // Explicitly encoded default values must be rejected
+  const defaultChecks = {
+    '1': 'if (bytes(value).length == 0) {',
+    '2': 'if (value == 0) {',
+    '3': 'if (value == 0) {',
+    '4': 'if (!value) {',
+    '5': 'if (length == 0) {',
+    '6': 'if (value == 0) {',
+  };
+  for (const [number, check] of Object.entries(defaultChecks)) {
+    if (!branches[number].includes(`${check}\n\t\t\t\treturn (false, pos); // Default values must be omitted`)) {
+      console.error(`❌ Field ${number} accepts an encoded default value`);
+      process.exit(1);
+    }
+  }
+
+  // Empty packed arrays must be rejected
+  if (!/\/\/ Empty packed arrays must be omitted instead of encoded\s*if \(size == 0\) \{\s*return \(false, pos\);/.test(branches['7'])) {
+    console.error('❌ Packed field accepts an empty array');
+    process.exit(1);
+  }
+
+  // Fields tracking their presence are encoded when set to their default value
+  if (branches['8'].includes('Default values must be omitted')) {
+    console.error('❌ Optional field rejects its default value');
+    process.exit(1);
+  }
+
+  console.log('✅ Strict canonical decoding test passed');
+}
+
+// Run the test
+testStrictCanonical();
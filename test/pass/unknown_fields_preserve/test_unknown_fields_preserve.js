@@ -0,0 +1,50 @@
+const fs = require('fs');
+const path = require('path');
+
+// Test: Check that preserved unknown fields are written back in field number order, between the known fields
+function testUnknownFieldsPreserve() {
+  const solFile = path.join(__dirname, 'unknown_fields_preserve/unknown_fields_preserve.sol');
+
+  if (!fs.existsSync(solFile)) {
+    console.error('❌ Test file not generated');
+    process.exit(1);
+  }
+
+  const solContent = fs.readFileSync(solFile, 'utf8');
+
+  if (!/struct Record \{[^}]*bytes _unknown;/.test(solContent)) {
+    console.error('❌ Record does not preserve unknown fields');
+    process.exit(1);
+  }
+
+  // Unknown fields are decoded by appending their raw key and value
+  if (!/if \(!is_known_field\(field_number\)\) \{\s*\(success, pos\) = skip_field\(pos, buf, wire_type\);[^]*?bytes memory raw = Unknown_fields_preserve\.copy_bytes\(buf, key_pos, pos - key_pos\);\s*instance\._unknown = bytes\.concat\(instance\._unknown, raw\);/.test(solContent)) {
+    console.error('❌ Decoder does not preserve unknown fields');
+    process.exit(1);
+  }
+
+  // Unknown fields numbered below each known field are written before it, the rest after the last one
+  const order = [
+    /\(pos, unknown_pos\) = encode_unknown\(pos, buf, instance, unknown_pos, 1\);\s*pos = encode_1\(pos, buf, instance\);/,
+    /\(pos, unknown_pos\) = encode_unknown\(pos, buf, instance, unknown_pos, 2\);\s*pos = encode_2\(pos, buf, instance\);/,
+    /\(pos, unknown_pos\) = encode_unknown\(pos, buf, instance, unknown_pos, 4\);\s*pos = encode_4\(pos, buf, instance\);/,
+    /pos = encode_4\(pos, buf, instance\);\s*\(pos, \) = encode_unknown\(pos, buf, instance, unknown_pos, 0xFFFFFFFFFFFFFFFF\);/,
+  ];
+  for (const pattern of order) {
+    if (!pattern.test(solContent)) {
+      console.error(`❌ Unknown fields are not merged in field number order: ${pattern}`);
+      process.exit(1);
+    }
+  }
+
+  // Unknown fields are copied with the slice helper instead of a byte loop
+  if (!/write_bytes\(buf, pos, instance\._unknown, unknown_pos, end_pos - unknown_pos\)/.test(solContent) ||
+      /buf\[pos \+ i\] = instance\._unknown\[i\];/.test(solContent)) {
+    console.error('❌ Unknown fields are not copied with write_bytes');
+    process.exit(1);
+  }
+
+  console.log('✅ Preserved unknown fields keep their order');
+}
+
+testUnknownFieldsPreserve();
@@ -0,0 +1,67 @@
+const fs = require('fs');
+const path = require('path');
+
+// Test: Check that skipped unknown fields are advanced past by their wire type and dropped
+function testUnknownFieldsSkip() {
+  const solFile = path.join(__dirname, 'unknown_fields_skip/unknown_fields_skip.sol');
+
+  if (!fs.existsSync(solFile)) {
+    console.error('❌ Test file not generated');
+    process.exit(1);
+  }
+
+  const solContent = fs.readFileSync(solFile, 'utf8');
+
+  // Only the fields of this version of the schema are known
+  const knownField = solContent.match(/function is_known_field\(uint64 field_number\) internal pure returns \(bool\) \{([^]*?)\n\t\}/);
+  if (!knownField) {
+    console.error('❌ is_known_field not generated');
+    process.exit(1);
+  }
+  const known = [...knownField[1].matchAll(/if \(field_number == (\d+)\) \{\s*return true;/g)].map(m => m[1]);
+  if (known.join(',') !== '1,2,4' || !/return false;\s*$/.test(knownField[1])) {
+    console.error(`❌ is_known_field accepts fields ${known}, want 1,2,4`);
+    process.exit(1);
+  }
+
+  // Every wire type but groups is skipped, without reading past the buffer
+  const skipField = solContent.match(/function skip_field\(uint64 pos, bytes memory buf, ProtobufLib\.WireType wire_type\) internal pure returns \(bool, uint64\) \{([^]*?)\n\t\}/);
+  if (!skipField) {
+    console.error('❌ skip_field not generated');
+    process.exit(1);
+  }
+  const skips = [
+    /wire_type == ProtobufLib\.WireType\.Varint\) \{\s*uint64 value;\s*\(success, new_pos, value\) = ProtobufLib\.decode_varint\(pos, buf\);/,
+    /wire_type == ProtobufLib\.WireType\.Bits64\) \{\s*new_pos = pos \+ 8;/,
+    /wire_type == ProtobufLib\.WireType\.Bits32\) \{\s*new_pos = pos \+ 4;/,
+    /wire_type == ProtobufLib\.WireType\.LengthDelimited\) \{\s*uint64 size;\s*\(success, new_pos, size\) = ProtobufLib\.decode_length_delimited\(pos, buf\);[^]*?new_pos \+= size;/,
+    /\} else \{\s*return \(false, pos\); \/\/ Groups are not supported/,
+    /if \(new_pos > buf\.length\) \{\s*return \(false, pos\);\s*\}\s*return \(true, new_pos\);/,
+  ];
+  for (const pattern of skips) {
+    if (!pattern.test(skipField[1])) {
+      console.error(`❌ skip_field does not handle: ${pattern}`);
+      process.exit(1);
+    }
+  }
+
+  // Unknown fields are skipped instead of rejected, in field number order
+  if (/if \(field_number > 4\) \{/.test(solContent)) {
+    console.error('❌ Decoder rejects field numbers above the known ones');
+    process.exit(1);
+  }
+  if (!/if \(!is_known_field\(field_number\)\) \{\s*\(success, pos\) = skip_field\(pos, buf, wire_type\);\s*if \(!success\) \{\s*return \(false, pos, instance\);\s*\}\s*previous_field_number = field_number;\s*continue;\s*\}/.test(solContent)) {
+    console.error('❌ Decoder does not skip unknown fields');
+    process.exit(1);
+  }
+
+  // Skipped fields are dropped, so encoding writes the known fields only
+  if (/_unknown|encode_unknown/.test(solContent)) {
+    console.error('❌ Skipped unknown fields are kept');
+    process.exit(1);
+  }
+
+  console.log('✅ Unknown fields are skipped');
+}
+
+testUnknownFieldsSkip();
@@ -0,0 +1,73 @@
+const fs = require('fs');
+const path = require('path');
+
+function escapeRegExp(text) {
+  return text.replace(/[.*+?^${}()|[\]\\]/g, '\\$&');
+}
+
+// Test: Check that validate=true generates validation functions from buf.validate rules
+function testValidationRules() {
+  const solFile = path.join(__dirname, 'validation_rules/validation_rules.sol');
+
+  if (!fs.existsSync(solFile)) {
+    console.error('❌ Test file not generated');
+    process.exit(1);
+  }
+
+  const solContent = fs.readFileSync(solFile, 'utf8');
+
+  if (!/function validate_fields\(Validation_rules\.Order memory instance\) internal pure returns \(bool, string memory\)/.test(solContent) ||
+      !/function validate\(Validation_rules\.Order memory instance\) internal pure returns \(bool, string memory\)/.test(solContent)) {
+    console.error('❌ Order validation functions not generated');
+    process.exit(1);
+  }
+
+  const expectedChecks = [
+    ['instance.amount < 1', 'amount: uint64.gte'],
+    ['instance.amount > 1000000', 'amount: uint64.lte'],
+    ['instance.priority != 1 && instance.priority != 2 && instance.priority != 3', 'priority: int32.in'],
+    ['bytes(instance.symbol).length != 3', 'symbol: string.len_bytes'],
+    ['instance.memo.length > 32', 'memo: bytes.max_len'],
+    ['uint256(instance.side) == 0', 'side: enum.not_in'],
+    ['CounterpartyCodec.equals(instance.counterparty, empty)', 'counterparty: required'],
+    ['instance.fills.length > 10', 'fills: repeated.max_items'],
+    ['instance.offset <= 10 && instance.offset >= -10', 'offset: sint64.gt_lt_exclusive'],
+    ['Validation_rules.utf8_length(instance.name) < 1', 'name: string.min_len'],
+  ];
+  for (const [condition, reason] of expectedChecks) {
+    const check = new RegExp(escapeRegExp(`if (${condition}) {`) + '\\s*' + escapeRegExp(`return (false, "${reason}");`));
+    if (!check.test(solContent)) {
+      console.error(`❌ Missing check for ${reason}`);
+      process.exit(1);
+    }
+  }
+
+  // Embedded messages are validated with their own codec
+  if (!solContent.includes('(valid, reason) = CounterpartyCodec.validate(instance.counterparty);')) {
+    console.error('❌ Order validation does not descend into its counterparty');
+    process.exit(1);
+  }
+
+  // buf.validate example values neither constrain the field nor make it ignore empty values
+  if (!/returns \(bool, string memory\) \{\s*if \(instance\.amount < 1\)/.test(solContent)) {
+    console.error('❌ buf.validate example values read as protoc-gen-validate ignore_empty');
+    process.exit(1);
+  }
+
+  // validate_on_decode=true makes decoders reject invalid messages
+  if ((solContent.match(/bool valid;\s*\(valid, \) = validate_fields\(instance\);\s*if \(!valid\)/g) || []).length !== 2) {
+    console.error('❌ Decoders do not validate the messages they decode');
+    process.exit(1);
+  }
+
+  // The rules definition is not imported by the generated code
+  if (/import ".*validate\.sol";/.test(solContent)) {
+    console.error('❌ buf/validate/validate.proto should not be imported');
+    process.exit(1);
+  }
+
+  console.log('✅ Validation functions properly generated');
+}
+
+// Run the test
+testValidationRules();